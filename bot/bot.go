@@ -15,6 +15,7 @@ import (
 	"github.com/depado/fox/cmd"
 	"github.com/depado/fox/commands"
 	"github.com/depado/fox/player"
+	"github.com/depado/fox/soundcloud"
 	"github.com/depado/fox/storage"
 )
 
@@ -27,6 +28,7 @@ type Bot struct {
 	players     *player.Players
 	storage     *storage.BoltStorage
 	acl         *acl.ACL
+	sp          *soundcloud.SoundCloudProvider
 }
 
 type CommandMap struct {
@@ -42,7 +44,7 @@ func (cm *CommandMap) Get(c string) (commands.Command, bool) {
 	return co, ok
 }
 
-func NewBot(lc fx.Lifecycle, l zerolog.Logger, c *cmd.Conf, cmds []commands.Command, p *player.Players, storage *storage.BoltStorage, a *acl.ACL) *Bot {
+func NewBot(lc fx.Lifecycle, l zerolog.Logger, c *cmd.Conf, cmds []commands.Command, p *player.Players, storage *storage.BoltStorage, a *acl.ACL, sp *soundcloud.SoundCloudProvider) *Bot {
 	log := l.With().Str("component", "bot").Logger()
 	dg, err := discordgo.New("Bot " + c.Bot.Token)
 	if err != nil {
@@ -59,6 +61,7 @@ func NewBot(lc fx.Lifecycle, l zerolog.Logger, c *cmd.Conf, cmds []commands.Comm
 		commands:    &CommandMap{m: make(map[string]commands.Command)},
 		storage:     storage,
 		acl:         a,
+		sp:          sp,
 	}
 
 	for _, cmd := range cmds {
@@ -67,14 +70,27 @@ func NewBot(lc fx.Lifecycle, l zerolog.Logger, c *cmd.Conf, cmds []commands.Comm
 
 	b.session.AddHandler(b.MessageCreatedHandler)
 	b.session.AddHandler(b.GuildCreatedHandler)
-	b.session.AddHandler(func(s *discordgo.Session, vsu *discordgo.VoiceStateUpdate) {})
+	b.session.AddHandler(b.VoiceStateUpdateHandler)
+	b.session.AddHandler(b.InteractionCreateHandler)
 
 	if err := dg.Open(); err != nil {
 		log.Fatal().Err(err).Msg("unable to open")
 	}
 
+	switch c.Bot.Presence {
+	case PresenceLatest, PresenceGuild, PresenceRotate, PresenceSummary, PresenceOff:
+	default:
+		log.Warn().Str("presence", c.Bot.Presence).Msg("unrecognized presence policy, fallback to 'latest'")
+	}
+	if c.Bot.Presence == PresenceGuild && c.Bot.PresenceGuild == "" {
+		log.Warn().Msg("no guild configured for the 'guild' presence policy, the presence won't be updated")
+	}
+	quit := make(chan struct{})
+	go b.runPresence(quit)
+
 	lc.Append(fx.Hook{
 		OnStop: func(c context.Context) error {
+			close(quit)
 			b.log.Debug().Str("lifecycle", "stop").Msg("killing players")
 			b.players.Kill()
 			b.session.Close()
@@ -6,6 +6,7 @@ import (
 
 	"github.com/bwmarrin/discordgo"
 
+	"github.com/depado/fox/models"
 	"github.com/depado/fox/storage"
 )
 
@@ -48,6 +49,27 @@ func (b *Bot) GuildCreatedHandler(s *discordgo.Session, g *discordgo.GuildCreate
 		return
 	}
 	b.log.Debug().Str("guild", g.ID).Str("name", g.Name).Msg("registered new player")
+	b.RestoreSession(g.ID, gc)
+}
+
+// RestoreSession will restore the queue and player state saved for the guild,
+// and resume playback if the guild is set up to do so.
+func (b *Bot) RestoreSession(guildID string, gc *models.Conf) {
+	p := b.players.GetPlayer(guildID)
+	if p == nil {
+		return
+	}
+
+	sess, err := b.storage.GetSession(guildID)
+	if err != nil {
+		if !errors.Is(err, storage.ErrSessionNotFound) {
+			b.log.Err(err).Msg("unable to fetch saved session")
+		}
+		return
+	}
+	tr := b.sp.RestoreTracks(sess.Tracks)
+	p.Restore(sess, tr, gc.Resume)
+	b.log.Debug().Str("guild", guildID).Int("tracks", len(tr)).Msg("restored session")
 }
 
 func (b *Bot) MessageInviter(s *discordgo.Session, g *discordgo.GuildCreate) {
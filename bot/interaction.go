@@ -0,0 +1,30 @@
+package bot
+
+import (
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+
+	"github.com/depado/fox/commands"
+)
+
+// InteractionCreateHandler dispatches the clicks on message components to the
+// command which sent them, according to their custom ID.
+func (b *Bot) InteractionCreateHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.Type != discordgo.InteractionMessageComponent || i.GuildID == "" {
+		return
+	}
+
+	args := strings.Split(i.MessageComponentData().CustomID, ":")
+	c, ok := b.commands.Get(args[0])
+	if !ok {
+		b.log.Warn().Str("custom_id", i.MessageComponentData().CustomID).Msg("interaction for unknown command")
+		return
+	}
+	ic, ok := c.(commands.Interactive)
+	if !ok {
+		b.log.Warn().Str("command", args[0]).Msg("command doesn't handle interactions")
+		return
+	}
+	ic.Interaction(s, i, args[1:])
+}
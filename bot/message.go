@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/depado/fox/acl"
+	"github.com/depado/fox/commands"
 	"github.com/depado/fox/message"
 	"github.com/bwmarrin/discordgo"
 )
@@ -17,9 +18,23 @@ func (b *Bot) InitialCheck(s *discordgo.Session, m *discordgo.MessageCreate) boo
 	return strings.HasPrefix(m.Content, b.conf.Bot.Prefix) && m.Author.ID != s.State.User.ID && !m.Author.Bot
 }
 
+// FollowUp will pass a message that isn't a command to the commands waiting
+// for a reply, until one of them handles it.
+func (b *Bot) FollowUp(s *discordgo.Session, m *discordgo.MessageCreate) {
+	if m.GuildID == "" || m.Author.ID == s.State.User.ID || m.Author.Bot {
+		return
+	}
+	for _, c := range b.allCommands {
+		if f, ok := c.(commands.Follower); ok && f.FollowUp(s, m.Message) {
+			return
+		}
+	}
+}
+
 func (b *Bot) MessageCreatedHandler(s *discordgo.Session, m *discordgo.MessageCreate) {
 	// Quick check for prefix and to not react to itself
 	if !b.InitialCheck(s, m) {
+		b.FollowUp(s, m)
 		return
 	}
 
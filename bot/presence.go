@@ -0,0 +1,138 @@
+package bot
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/depado/fox/cmd"
+	"github.com/depado/fox/player"
+	"github.com/depado/fox/tracks"
+)
+
+// The presence of the bot is shared by all the guilds, so these policies
+// decide which of the playing tracks is shown
+const (
+	// PresenceLatest shows the track that started last in any guild
+	PresenceLatest = "latest"
+	// PresenceGuild only shows the track playing in the configured guild
+	PresenceGuild = "guild"
+	// PresenceRotate cycles through the tracks playing in every guild
+	PresenceRotate = "rotate"
+	// PresenceSummary shows the track when a single guild is playing, and the
+	// number of guilds otherwise
+	PresenceSummary = "summary"
+	// PresenceOff never changes the presence
+	PresenceOff = "off"
+)
+
+// presenceThrottle is the minimum delay between two presence updates, Discord
+// only allows a few of them per minute
+const presenceThrottle = 5 * time.Second
+
+// presence keeps track of what is playing in each guild to decide what to
+// show in the bot's presence
+type presence struct {
+	policy string
+	guild  string
+	rotate time.Duration
+
+	playing map[string]tracks.Track
+	latest  string
+	index   int
+	rotated time.Time
+	shown   string
+}
+
+func newPresence(c cmd.BotConf) *presence {
+	return &presence{
+		policy:  c.Presence,
+		guild:   c.PresenceGuild,
+		rotate:  c.PresenceRotate,
+		playing: make(map[string]tracks.Track),
+	}
+}
+
+// handle will keep track of the playing tracks according to the event
+func (pr *presence) handle(ev player.Event) {
+	switch ev.Type {
+	case player.TrackStarted:
+		pr.playing[ev.Guild] = ev.Track
+		pr.latest = ev.Guild
+	case player.TrackEnded, player.TrackFailed, player.Disconnected:
+		delete(pr.playing, ev.Guild)
+	}
+}
+
+// status returns the name of the listening activity to show, or an empty
+// string to clear it
+func (pr *presence) status(now time.Time) string {
+	guilds := make([]string, 0, len(pr.playing))
+	for g := range pr.playing {
+		guilds = append(guilds, g)
+	}
+	if len(guilds) == 0 {
+		return ""
+	}
+	sort.Strings(guilds)
+
+	switch pr.policy {
+	case PresenceGuild:
+		if t, ok := pr.playing[pr.guild]; ok {
+			return t.ListenStatus()
+		}
+		return ""
+	case PresenceRotate:
+		if now.Sub(pr.rotated) >= pr.rotate {
+			pr.index++
+			pr.rotated = now
+		}
+		return pr.playing[guilds[pr.index%len(guilds)]].ListenStatus()
+	case PresenceSummary:
+		if len(guilds) > 1 {
+			return fmt.Sprintf("music in %d servers", len(guilds))
+		}
+		return pr.playing[guilds[0]].ListenStatus()
+	default:
+		if t, ok := pr.playing[pr.latest]; ok {
+			return t.ListenStatus()
+		}
+		// The last guild to start a track stopped, show any other one
+		return pr.playing[guilds[0]].ListenStatus()
+	}
+}
+
+// runPresence will update the presence of the bot to show what is playing
+// until quit is closed. Updates are throttled so that quick track changes
+// don't hit the rate limit.
+func (b *Bot) runPresence(quit <-chan struct{}) {
+	pr := newPresence(b.conf.Bot)
+	if pr.policy == PresenceOff {
+		return
+	}
+
+	evs, unsubscribe := b.players.Subscribe()
+	defer unsubscribe()
+
+	tick := time.NewTicker(presenceThrottle)
+	defer tick.Stop()
+
+	for {
+		select {
+		case <-quit:
+			return
+		case ev := <-evs:
+			pr.handle(ev)
+		case now := <-tick.C:
+			st := pr.status(now)
+			if st == pr.shown {
+				continue
+			}
+			if err := b.session.UpdateListeningStatus(st); err != nil {
+				b.log.Err(err).Msg("unable to update presence")
+				continue
+			}
+			pr.shown = st
+		}
+	}
+}
@@ -0,0 +1,15 @@
+package bot
+
+import (
+	"github.com/bwmarrin/discordgo"
+)
+
+// VoiceStateUpdateHandler keeps track of the listeners in the voice channel the
+// guild's player is connected to.
+func (b *Bot) VoiceStateUpdateHandler(s *discordgo.Session, v *discordgo.VoiceStateUpdate) {
+	p := b.players.GetPlayer(v.GuildID)
+	if p == nil {
+		return
+	}
+	p.UpdateListeners()
+}
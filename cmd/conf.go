@@ -5,6 +5,7 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
@@ -19,8 +20,14 @@ type LogConf struct {
 }
 
 type BotConf struct {
-	Token  string `mapstructure:"token"`
-	Prefix string `mapstructure:"prefix"`
+	Token        string        `mapstructure:"token"`
+	Prefix       string        `mapstructure:"prefix"`
+	LeaveTimeout time.Duration `mapstructure:"leave_timeout"`
+	Retries      int           `mapstructure:"retries"`
+
+	Presence       string        `mapstructure:"presence"`
+	PresenceGuild  string        `mapstructure:"presence_guild"`
+	PresenceRotate time.Duration `mapstructure:"presence_rotate"`
 }
 
 type DatabaseConf struct {
@@ -1,6 +1,8 @@
 package cmd
 
 import (
+	"time"
+
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -17,6 +19,11 @@ func AddBotFlags(c *cobra.Command) {
 	c.PersistentFlags().String("bot.prefix", "!fox", "prefix to call the bot")
 	c.PersistentFlags().String("bot.token", "", "private bot token")
 	c.PersistentFlags().Int("bot.max_guilds", 5, "maximum number of guilds this instance can handle")
+	c.PersistentFlags().Duration("bot.leave_timeout", 5*time.Minute, "time to wait before leaving an empty voice channel")
+	c.PersistentFlags().Int("bot.retries", 3, "number of attempts to recover a failing track before skipping it")
+	c.PersistentFlags().String("bot.presence", "latest", `track shown in the presence, one of "latest", "guild", "rotate", "summary" or "off"`)
+	c.PersistentFlags().String("bot.presence_guild", "", `ID of the guild whose track is shown with the "guild" presence`)
+	c.PersistentFlags().Duration("bot.presence_rotate", 30*time.Second, `time each track is shown with the "rotate" presence`)
 }
 
 func AddDatabaseFlags(c *cobra.Command) {
@@ -2,6 +2,7 @@ package commands
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
@@ -12,16 +13,57 @@ import (
 	"github.com/depado/fox/message"
 	"github.com/depado/fox/player"
 	"github.com/depado/fox/soundcloud"
+	"github.com/depado/fox/tracks"
 )
 
+// sendAdmission will send the embed of the added tracks along with the reasons
+// why some of them were left out, or only these reasons if none was added.
+func sendAdmission(s *discordgo.Session, m *discordgo.Message, e *discordgo.MessageEmbed, a player.Admission, desc string, log zerolog.Logger) {
+	if a.Added == 0 {
+		if err := message.SendTimedReply(s, m, "", "Nothing was added to the queue\n"+a.String(), "", 10*time.Second); err != nil {
+			log.Err(err).Msg("unable to send timed reply")
+		}
+		return
+	}
+
+	if _, err := s.ChannelMessageSendEmbed(m.ChannelID, admissionEmbed(e, a, desc)); err != nil {
+		log.Err(err).Msg("unable to send embed")
+	}
+}
+
+// admissionEmbed sets the description of the embed of the added tracks, along
+// with the reasons why some of them were left out, or replaces it with these
+// reasons if none was added.
+func admissionEmbed(e *discordgo.MessageEmbed, a player.Admission, desc string) *discordgo.MessageEmbed {
+	if a.Added == 0 {
+		return &discordgo.MessageEmbed{
+			Title:       "Nothing was added to the queue",
+			Description: a.String(),
+			Color:       0xff5500,
+		}
+	}
+
+	e.Description = desc
+	if a.Dropped() > 0 {
+		e.Description += "\n" + a.String()
+	}
+	return e
+}
+
 type add struct {
 	BaseCommand
-	sp *soundcloud.SoundCloudProvider
+	sp     *soundcloud.SoundCloudProvider
+	search Command
 }
 
 func (c *add) Handler(s *discordgo.Session, m *discordgo.Message, args []string) {
 	url := args[0]
 	url = strings.Trim(url, "<>")
+	if !strings.HasPrefix(url, "https://") {
+		// Anything that isn't a URL is searched for
+		c.search.Handler(s, m, args)
+		return
+	}
 	if !strings.HasPrefix(url, "https://soundcloud.com") {
 		if err := message.SendTimedReply(s, m, "", "This doesn't look like a SoundCloud URL", "", 5*time.Second); err != nil {
 			c.log.Err(err).Msg("unable to send timed reply")
@@ -30,29 +72,49 @@ func (c *add) Handler(s *discordgo.Session, m *discordgo.Message, args []string)
 		return
 	}
 
+	// Optional position in queue, as in "add <url> at 3"
+	var pos int
+	if len(args) > 1 {
+		var err error
+		if len(args) != 3 || args[1] != "at" {
+			message.SendShortTimedNotice(s, m, "Use `add <url> at <position>` to add tracks at a given position in queue", c.log)
+			return
+		}
+		if pos, err = strconv.Atoi(args[2]); err != nil || pos < 1 {
+			message.SendShortTimedNotice(s, m, "The position must be a number starting at 1 for the next track", c.log)
+			return
+		}
+	}
+
 	p := c.Players.GetPlayer(m.GuildID)
 	if p == nil {
 		c.log.Error().Msg("no player associated to guild ID")
 		return
 	}
 
+	limits := p.GuildConf().Limits
+	add := func(t ...tracks.Track) player.Admission {
+		if pos > 0 {
+			return p.Queue.InsertWithin(limits, pos, t...)
+		}
+		return p.Queue.AppendWithin(limits, t...)
+	}
+	where := "to end of queue"
+	if pos > 0 {
+		where = fmt.Sprintf("at position %d in queue", pos)
+	}
+
 	tr, e, err := c.sp.GetPlaylist(url, m)
 	if err == nil {
-		p.Queue.Append(tr...)
-		e.Description = fmt.Sprintf("Added **%d** tracks to end of queue", len(tr))
-		if _, err = s.ChannelMessageSendEmbed(m.ChannelID, e); err != nil {
-			c.log.Err(err).Msg("unable to send embed")
-		}
+		a := add(tr...)
+		sendAdmission(s, m, e, a, fmt.Sprintf("Added **%d** tracks %s", a.Added, where), c.log)
 		return
 	}
 
 	t, e, err := c.sp.GetTrack(url, m)
 	if err == nil {
-		p.Queue.Append(t)
-		e.Description = "Added one tracks to end of queue"
-		if _, err = s.ChannelMessageSendEmbed(m.ChannelID, e); err != nil {
-			c.log.Err(err).Msg("unable to send embed")
-		}
+		a := add(t)
+		sendAdmission(s, m, e, a, "Added one tracks "+where, c.log)
 		return
 	}
 
@@ -61,10 +123,11 @@ func (c *add) Handler(s *discordgo.Session, m *discordgo.Message, args []string)
 	}
 }
 
-func NewAddCommand(p *player.Players, log zerolog.Logger, sp *soundcloud.SoundCloudProvider) Command {
+func NewAddCommand(p *player.Players, log zerolog.Logger, sp *soundcloud.SoundCloudProvider, search Command) Command {
 	cmd := "add"
 	return &add{
-		sp: sp,
+		sp:     sp,
+		search: search,
 		BaseCommand: BaseCommand{
 			ChannelRestriction: acl.Music,
 			RoleRestriction:    acl.Anyone,
@@ -79,9 +142,17 @@ func NewAddCommand(p *player.Players, log zerolog.Logger, sp *soundcloud.SoundCl
 				ShortDesc: "Add a track or playlist to the end of queue",
 				Description: "This command can be used to add tracks and " +
 					"complete playlists to the end of the queue. " +
-					"It currently only suppports soundcloud URLs.",
+					"It currently only suppports soundcloud URLs, anything " +
+					"else is searched for like the `search` command does. " +
+					"Tracks exceeding the limits of the server are left out. " +
+					"A position can be given to insert the tracks in queue " +
+					"instead, starting at 1 for the next track. With the " +
+					"fair queue enabled, the tracks are then laid out in " +
+					"rounds of requesters again.",
 				Examples: []Example{
 					{Command: "add <url>", Explanation: "Add the track to the end of queue"},
+					{Command: "add <url> at 3", Explanation: "Add the track at the third position in queue"},
+					{Command: "add daft punk", Explanation: "Search for tracks and playlists to add"},
 					{Command: "a <url>", Explanation: "Add the track using the alias"},
 				},
 			},
@@ -113,23 +184,18 @@ func (c *next) Handler(s *discordgo.Session, m *discordgo.Message, args []string
 		return
 	}
 
+	limits := p.GuildConf().Limits
 	tr, e, err := c.sp.GetPlaylist(url, m)
 	if err == nil {
-		p.Queue.Prepend(tr...)
-		e.Description = fmt.Sprintf("Added **%d** tracks to start of queue", len(tr))
-		if _, err = s.ChannelMessageSendEmbed(m.ChannelID, e); err != nil {
-			c.log.Err(err).Msg("unable to send embed")
-		}
+		a := p.Queue.PrependWithin(limits, tr...)
+		sendAdmission(s, m, e, a, fmt.Sprintf("Added **%d** tracks to start of queue", a.Added), c.log)
 		return
 	}
 
 	t, e, err := c.sp.GetTrack(url, m)
 	if err == nil {
-		p.Queue.Prepend(t)
-		e.Description = "Added one tracks to start of queue"
-		if _, err = s.ChannelMessageSendEmbed(m.ChannelID, e); err != nil {
-			c.log.Err(err).Msg("unable to send embed")
-		}
+		a := p.Queue.PrependWithin(limits, t)
+		sendAdmission(s, m, e, a, "Added one tracks to start of queue", c.log)
 		return
 	}
 
@@ -156,7 +222,10 @@ func NewNextCommand(p *player.Players, log zerolog.Logger, sp *soundcloud.SoundC
 				ShortDesc: "Add a track or playlist at the start of queue",
 				Description: "This command can be used to add tracks and " +
 					"complete playlists at the start of the queue. " +
-					"It currently only suppports soundcloud URLs.",
+					"It currently only suppports soundcloud URLs. " +
+					"When the queue is fair, the first track is played next " +
+					"and the other tracks of the requester take their " +
+					"following turns.",
 				Examples: []Example{
 					{Command: "next <url>", Explanation: "Add the track to the start of queue"},
 					{Command: "n <url>", Explanation: "Add the track using the alias"},
@@ -0,0 +1,81 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/rs/zerolog"
+
+	"github.com/depado/fox/acl"
+	"github.com/depado/fox/message"
+	"github.com/depado/fox/player"
+)
+
+type autoplay struct {
+	BaseCommand
+}
+
+func (c *autoplay) Handler(s *discordgo.Session, m *discordgo.Message, args []string) {
+	p := c.Players.GetPlayer(m.GuildID)
+	if p == nil {
+		c.log.Error().Msg("no player associated to guild ID")
+		return
+	}
+
+	on := !p.GuildConf().Autoplay
+	if len(args) > 0 {
+		switch args[0] {
+		case "on":
+			on = true
+		case "off":
+			on = false
+		default:
+			message.SendShortTimedNotice(s, m, "Use either `on` or `off`", c.log)
+			return
+		}
+	}
+
+	if err := p.SetAutoplay(on); err != nil {
+		c.log.Err(err).Msg("unable to set autoplay")
+		message.SendShortTimedNotice(s, m, "I couldn't save this setting", c.log)
+		return
+	}
+
+	msg := fmt.Sprintf("📻 Autoplay disabled by <@%s>", m.Author.ID)
+	if on {
+		msg = fmt.Sprintf("📻 Autoplay enabled by <@%s>, I'll keep playing related tracks when the queue runs dry", m.Author.ID)
+	}
+	if err := message.SendReply(s, m, "", msg, ""); err != nil {
+		c.log.Err(err).Msg("unable to send reply")
+	}
+}
+
+func NewAutoplayCommand(p *player.Players, log zerolog.Logger) Command {
+	cmd := "autoplay"
+	return &autoplay{
+		BaseCommand{
+			ChannelRestriction: acl.Music,
+			RoleRestriction:    acl.Privileged,
+			Options: Options{
+				ArgsRequired:      false,
+				DeleteUserMessage: true,
+			},
+			Long:    cmd,
+			Aliases: []string{"radio"},
+			Help: Help{
+				Usage:     cmd,
+				ShortDesc: "Keep playing related tracks when the queue is empty",
+				Description: "This command toggles autoplay. When enabled and the " +
+					"queue runs dry, tracks related to the last played ones are " +
+					"added to the queue, leaving out the tracks found in history.",
+				Examples: []Example{
+					{Command: "autoplay", Explanation: "Toggle autoplay"},
+					{Command: "autoplay on", Explanation: "Enable autoplay"},
+					{Command: "radio off", Explanation: "Disable autoplay using the alias"},
+				},
+			},
+			Players: p,
+			log:     log.With().Str("command", cmd).Logger(),
+		},
+	}
+}
@@ -7,26 +7,39 @@ import (
 	"github.com/rs/zerolog"
 
 	"github.com/depado/fox/acl"
+	"github.com/depado/fox/message"
 	"github.com/depado/fox/player"
 	"github.com/depado/fox/soundcloud"
 	"github.com/depado/fox/storage"
 )
 
-func InitializeAllCommands(p *player.Players, l zerolog.Logger, sp *soundcloud.SoundCloudProvider, bs *storage.BoltStorage) []Command {
+func InitializeAllCommands(p *player.Players, l zerolog.Logger, sp *soundcloud.SoundCloudProvider, bs *storage.BoltStorage, a *acl.ACL) []Command {
+	search := NewSearchCommand(p, l, sp)
 	return []Command{
 		NewPlayCommand(p, l),
 		NewPauseCommand(p, l),
 		NewStopCommand(p, l),
 		NewVolumeCommand(p, l),
 		NewNowPlayingCommand(p, l),
-		NewQueueCommand(p, l),
-		NewAddCommand(p, l, sp),
+		NewQueueCommand(p, l, a),
+		NewAddCommand(p, l, sp, search),
 		NewNextCommand(p, l, sp),
+		search,
 		NewJamCommand(p, l),
-		NewSkipCommand(p, l),
-		NewRemoveCommand(p, l),
+		NewSkipCommand(p, l, a),
+		NewSeekCommand(p, l),
+		NewLoopCommand(p, l),
+		NewFilterCommand(p, l),
+		NewSummonCommand(p, l),
+		NewRemoveCommand(p, l, a),
+		NewHistoryCommand(p, l),
+		NewPreviousCommand(p, l),
+		NewAutoplayCommand(p, l),
+		NewSleepCommand(p, l),
+		NewPanelCommand(p, l),
 		NewStatsCommand(p, l),
-		NewSetupCommand(p, l, bs),
+		NewSetupCommand(p, l),
+		NewScheduleCommand(p, l, bs),
 	}
 }
 
@@ -39,6 +52,20 @@ type Command interface {
 	Opts() Options
 }
 
+// Interactive is implemented by the commands sending messages with components.
+// The custom ID of these components starts with the long call of the command,
+// followed by the arguments passed to Interaction, separated by colons.
+type Interactive interface {
+	Interaction(s *discordgo.Session, i *discordgo.InteractionCreate, args []string)
+}
+
+// Follower is implemented by the commands waiting for a reply to one of their
+// messages, such as a number picking a search result. FollowUp receives the
+// messages that aren't commands and returns whether it handled the message.
+type Follower interface {
+	FollowUp(s *discordgo.Session, m *discordgo.Message) bool
+}
+
 type Options struct {
 	ArgsRequired      bool
 	DeleteUserMessage bool
@@ -156,3 +183,19 @@ func (c BaseCommand) DisplayHelp(s *discordgo.Session, m *discordgo.Message, pre
 		c.log.Err(err).Msg("unable to send embed")
 	}
 }
+
+// requirePrivileged checks that the author of the message is privileged, for
+// commands open to anyone with some privileged actions. A notice is sent when
+// they aren't.
+func requirePrivileged(s *discordgo.Session, m *discordgo.Message, a *acl.ACL, log zerolog.Logger) bool {
+	ok, err := a.Check(s, m, acl.Privileged, acl.Music)
+	if err != nil {
+		log.Err(err).Msg("unable to check acl")
+		return false
+	}
+	if !ok {
+		msg := fmt.Sprintf("You do not have permission to do that.\n**%s**", acl.RestrictionString(acl.Music, acl.Privileged))
+		message.SendShortTimedNotice(s, m, msg, log)
+	}
+	return ok
+}
@@ -0,0 +1,100 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/rs/zerolog"
+
+	"github.com/depado/fox/acl"
+	"github.com/depado/fox/message"
+	"github.com/depado/fox/player"
+)
+
+type filter struct {
+	BaseCommand
+}
+
+func (c *filter) Handler(s *discordgo.Session, m *discordgo.Message, args []string) {
+	p := c.Players.GetPlayer(m.GuildID)
+	if p == nil {
+		c.log.Error().Msg("no player associated to guild ID")
+		return
+	}
+
+	if len(args) < 1 {
+		message.SendShortTimedNotice(s, m, fmt.Sprintf("🎛️ Active filters: %s", p.FiltersString()), c.log)
+		return
+	}
+
+	switch args[0] {
+	case "list", "l":
+		var body string
+		for _, f := range player.Filters {
+			body += fmt.Sprintf("`%s` %s\n", f.Name, f.Description)
+		}
+		if err := message.SendReply(s, m, "🎛️ Available filters", body, ""); err != nil {
+			c.log.Err(err).Msg("unable to send reply")
+		}
+		return
+	case "off", "o":
+		args = nil
+	}
+
+	for _, a := range args {
+		if _, ok := player.GetFilter(a); !ok {
+			message.SendShortTimedNotice(s, m, fmt.Sprintf("Unknown filter `%s`, use `filter list` to see the available ones", a), c.log)
+			return
+		}
+	}
+	if err := p.SetFilters(args...); err != nil {
+		c.log.Err(err).Msg("unable to set filters")
+		return
+	}
+
+	msg := fmt.Sprintf("🎛️ Filters disabled by <@%s>", m.Author.ID)
+	if len(args) > 0 {
+		msg = fmt.Sprintf("🎛️ <@%s> enabled the %s filters", m.Author.ID, strings.Join(args, ", "))
+	}
+	if err := message.SendReply(s, m, "", msg, ""); err != nil {
+		c.log.Err(err).Msg("unable to send reply")
+	}
+}
+
+func NewFilterCommand(p *player.Players, log zerolog.Logger) Command {
+	cmd := "filter"
+	return &filter{
+		BaseCommand{
+			ChannelRestriction: acl.Music,
+			RoleRestriction:    acl.Privileged,
+			Options: Options{
+				ArgsRequired:      false,
+				DeleteUserMessage: true,
+			},
+			Long:    cmd,
+			Aliases: []string{"fx"},
+			SubCommands: []SubCommand{
+				{Long: "list", Aliases: []string{"l"}, Description: "List the available filters"},
+				{Long: "off", Aliases: []string{"o"}, Description: "Disable all the filters"},
+			},
+			Help: Help{
+				Usage:     cmd,
+				ShortDesc: "Apply audio filters to the player",
+				Description: "This command will enable the given audio filters, " +
+					"replacing the active ones, or display the active filters if " +
+					"no argument is provided. Filters are applied immediately to " +
+					"the currently playing track and kept for the next ones.",
+				Examples: []Example{
+					{Command: "filter", Explanation: "Display the active filters"},
+					{Command: "filter list", Explanation: "List the available filters"},
+					{Command: "filter bassboost", Explanation: "Boost the bass"},
+					{Command: "filter nightcore 8d", Explanation: "Combine several filters"},
+					{Command: "filter off", Explanation: "Disable all the filters"},
+				},
+			},
+			Players: p,
+			log:     log.With().Str("command", cmd).Logger(),
+		},
+	}
+}
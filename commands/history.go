@@ -0,0 +1,147 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/rs/zerolog"
+
+	"github.com/depado/fox/acl"
+	"github.com/depado/fox/message"
+	"github.com/depado/fox/player"
+)
+
+type history struct {
+	BaseCommand
+}
+
+func (c *history) Handler(s *discordgo.Session, m *discordgo.Message, args []string) {
+	p := c.Players.GetPlayer(m.GuildID)
+	if p == nil {
+		c.log.Error().Msg("no player associated to guild ID")
+		return
+	}
+
+	if len(args) > 0 && (args[0] == "add" || args[0] == "a") {
+		if len(args) < 2 {
+			message.SendShortTimedNotice(s, m, "Which track should I add? Give me its number in history", c.log)
+			return
+		}
+		n, err := strconv.Atoi(args[1])
+		if err != nil {
+			message.SendShortTimedNotice(s, m, "The argument is invalid", c.log)
+			return
+		}
+		e, ok := p.History.Get(n)
+		if !ok {
+			message.SendShortTimedNotice(s, m, fmt.Sprintf("There is no track #%d in history", n), c.log)
+			return
+		}
+		a := p.Queue.AppendWithin(p.GuildConf().Limits, e.Track)
+		desc := fmt.Sprintf("⏮️ <@%s> added this track back to the end of queue", m.Author.ID)
+		sendAdmission(s, m, e.Track.Embed(false), a, desc, c.log)
+		return
+	}
+
+	page := 1
+	if len(args) > 0 {
+		var err error
+		if page, err = strconv.Atoi(args[0]); err != nil || page < 1 {
+			message.SendShortTimedNotice(s, m, "The page number is invalid", c.log)
+			return
+		}
+	}
+	if _, err := s.ChannelMessageSendEmbed(m.ChannelID, p.History.GenerateHistoryEmbed(page)); err != nil {
+		c.log.Err(err).Msg("unable to send embed")
+	}
+}
+
+func NewHistoryCommand(p *player.Players, log zerolog.Logger) Command {
+	cmd := "history"
+	return &history{
+		BaseCommand{
+			ChannelRestriction: acl.Music,
+			RoleRestriction:    acl.Anyone,
+			Options: Options{
+				ArgsRequired:      false,
+				DeleteUserMessage: true,
+			},
+			Long:    cmd,
+			Aliases: []string{"hist"},
+			SubCommands: []SubCommand{
+				{Long: "add", Aliases: []string{"a"}, Arg: "number", Description: "Add a track from history to the end of queue"},
+			},
+			Help: Help{
+				Usage:     cmd,
+				ShortDesc: "Display the recently played tracks",
+				Description: "This command will display the recently played " +
+					"tracks, most recent first, along with who added them. " +
+					"Tracks can be added back to the queue using their number.",
+				Examples: []Example{
+					{Command: "history", Explanation: "Display the last played tracks"},
+					{Command: "history 2", Explanation: "Display the second page of history"},
+					{Command: "history add 3", Explanation: "Add the third most recent track to the end of queue"},
+				},
+			},
+			Players: p,
+			log:     log.With().Str("command", cmd).Logger(),
+		},
+	}
+}
+
+type previous struct {
+	BaseCommand
+}
+
+func (c *previous) Handler(s *discordgo.Session, m *discordgo.Message, args []string) {
+	p := c.Players.GetPlayer(m.GuildID)
+	if p == nil {
+		c.log.Error().Msg("no player associated to guild ID")
+		return
+	}
+
+	t, err := p.Previous()
+	if err != nil {
+		if errors.Is(err, player.ErrEmptyHistory) {
+			message.SendShortTimedNotice(s, m, "No track was played yet", c.log)
+			return
+		}
+		c.log.Err(err).Msg("unable to play previous track")
+		return
+	}
+
+	msg := fmt.Sprintf("⏮️ <@%s> went back to %s", m.Author.ID, t.MarkdownLink())
+	if err := message.SendReply(s, m, "", msg, ""); err != nil {
+		c.log.Err(err).Msg("unable to send reply")
+	}
+}
+
+func NewPreviousCommand(p *player.Players, log zerolog.Logger) Command {
+	cmd := "previous"
+	return &previous{
+		BaseCommand{
+			ChannelRestriction: acl.Music,
+			RoleRestriction:    acl.Privileged,
+			Options: Options{
+				ArgsRequired:      false,
+				DeleteUserMessage: true,
+			},
+			Long:    cmd,
+			Aliases: []string{"back", "prev"},
+			Help: Help{
+				Usage:     cmd,
+				ShortDesc: "Play the previous track",
+				Description: "This command will play the last played track " +
+					"again. The current track will be played right after it.",
+				Examples: []Example{
+					{Command: "previous", Explanation: "Play the previous track"},
+					{Command: "back", Explanation: "Same using an alias"},
+				},
+			},
+			Players: p,
+			log:     log.With().Str("command", cmd).Logger(),
+		},
+	}
+}
@@ -0,0 +1,85 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/rs/zerolog"
+
+	"github.com/depado/fox/acl"
+	"github.com/depado/fox/message"
+	"github.com/depado/fox/player"
+)
+
+type loop struct {
+	BaseCommand
+}
+
+func (c *loop) Handler(s *discordgo.Session, m *discordgo.Message, args []string) {
+	p := c.Players.GetPlayer(m.GuildID)
+	if p == nil {
+		c.log.Error().Msg("no player associated to guild ID")
+		return
+	}
+
+	if len(args) < 1 {
+		message.SendShortTimedNotice(s, m, fmt.Sprintf("Loop mode is currently %s", p.Loop()), c.log)
+		return
+	}
+
+	var l player.LoopMode
+	switch args[0] {
+	case "off", "o":
+		l = player.LoopOff
+	case "track", "t", "one":
+		l = player.LoopTrack
+	case "queue", "q", "all":
+		l = player.LoopQueue
+	default:
+		message.SendShortTimedNotice(s, m, "Unknown loop mode, use `off`, `track` or `queue`", c.log)
+		return
+	}
+
+	p.SetLoop(l)
+	msg := fmt.Sprintf("Loop mode set to %s by <@%s>", l, m.Author.ID)
+	if err := message.SendReply(s, m, "", msg, ""); err != nil {
+		c.log.Err(err).Msg("unable to send reply")
+	}
+}
+
+func NewLoopCommand(p *player.Players, log zerolog.Logger) Command {
+	cmd := "loop"
+	return &loop{
+		BaseCommand{
+			ChannelRestriction: acl.Music,
+			RoleRestriction:    acl.Privileged,
+			Options: Options{
+				ArgsRequired:      false,
+				DeleteUserMessage: true,
+			},
+			Long:    cmd,
+			Aliases: []string{"repeat"},
+			SubCommands: []SubCommand{
+				{Long: "off", Aliases: []string{"o"}, Description: "Remove tracks from the queue once played"},
+				{Long: "track", Aliases: []string{"t", "one"}, Description: "Replay the current track indefinitely"},
+				{Long: "queue", Aliases: []string{"q", "all"}, Description: "Move played tracks to the back of the queue"},
+			},
+			Help: Help{
+				Usage:     cmd,
+				ShortDesc: "Set or see the loop mode",
+				Description: "This command will set the loop mode of the player " +
+					"or display the current one if no argument is provided. " +
+					"Skipping a track while repeating it will move on to the " +
+					"next track in queue.",
+				Examples: []Example{
+					{Command: "loop", Explanation: "Display the current loop mode"},
+					{Command: "loop track", Explanation: "Repeat the current track"},
+					{Command: "loop queue", Explanation: "Repeat the whole queue"},
+					{Command: "loop off", Explanation: "Disable looping"},
+				},
+			},
+			Players: p,
+			log:     log.With().Str("command", cmd).Logger(),
+		},
+	}
+}
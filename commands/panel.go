@@ -0,0 +1,84 @@
+package commands
+
+import (
+	"github.com/bwmarrin/discordgo"
+	"github.com/rs/zerolog"
+
+	"github.com/depado/fox/acl"
+	"github.com/depado/fox/message"
+	"github.com/depado/fox/player"
+)
+
+type panel struct {
+	BaseCommand
+}
+
+func (c *panel) Handler(s *discordgo.Session, m *discordgo.Message, args []string) {
+	p := c.Players.GetPlayer(m.GuildID)
+	if p == nil {
+		c.log.Error().Msg("no player associated to guild ID")
+		return
+	}
+
+	if len(args) > 0 {
+		switch args[0] {
+		case "on":
+		case "off":
+			if err := p.SetPanel(false); err != nil {
+				c.log.Err(err).Msg("unable to disable panel")
+				message.SendShortTimedNotice(s, m, "I couldn't save this setting", c.log)
+				return
+			}
+			message.SendShortTimedNotice(s, m, "Alright, I removed the now playing panel", c.log)
+			return
+		default:
+			message.SendShortTimedNotice(s, m, "Use either `on` or `off`, or no argument to pin the panel again", c.log)
+			return
+		}
+	}
+
+	if p.GuildConf().TextChannel == "" {
+		message.SendShortTimedNotice(s, m, "There is no music channel yet, an admin has to set one up using `setup text`", c.log)
+		return
+	}
+	if !p.GuildConf().Panel {
+		if err := p.SetPanel(true); err != nil {
+			c.log.Err(err).Msg("unable to enable panel")
+			message.SendShortTimedNotice(s, m, "I couldn't save this setting", c.log)
+			return
+		}
+	}
+	if err := p.RepinPanel(); err != nil {
+		c.log.Err(err).Msg("unable to pin panel")
+		message.SendShortTimedNotice(s, m, "I couldn't post the now playing panel", c.log)
+	}
+}
+
+func NewPanelCommand(p *player.Players, log zerolog.Logger) Command {
+	cmd := "panel"
+	return &panel{
+		BaseCommand{
+			ChannelRestriction: acl.Music,
+			RoleRestriction:    acl.Privileged,
+			Options: Options{
+				ArgsRequired:      false,
+				DeleteUserMessage: true,
+			},
+			Long: cmd,
+			Help: Help{
+				Usage:     cmd,
+				ShortDesc: "Pin a live now playing panel in the music channel",
+				Description: "This command posts a now playing panel at the bottom " +
+					"of the music channel and pins it, removing the previous one. " +
+					"The panel is kept up to date with the progress of the track, " +
+					"the upcoming tracks and the state of the player.",
+				Examples: []Example{
+					{Command: "panel", Explanation: "Enable the panel, or pin it again at the bottom"},
+					{Command: "panel off", Explanation: "Remove the panel and stop updating it"},
+				},
+			},
+			Players: p,
+			log:     log.With().Str("command", cmd).Logger(),
+		},
+	}
+}
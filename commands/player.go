@@ -1,6 +1,7 @@
 package commands
 
 import (
+	"errors"
 	"fmt"
 	"time"
 
@@ -40,6 +41,18 @@ func (c *play) Handler(s *discordgo.Session, m *discordgo.Message, args []string
 		return
 	}
 
+	if p.GuildConf().FollowCaller {
+		ch := callerVoiceChannel(s, m)
+		if ch == "" {
+			message.SendShortTimedNotice(s, m, "You need to be in a voice channel so I can join you", c.log)
+			return
+		}
+		if err := p.SetVoiceChannel(ch); err != nil {
+			c.log.Err(err).Msg("unable to set voice channel")
+			return
+		}
+	}
+
 	msg := fmt.Sprintf("▶️ Started playing for <@%s>", m.Author.ID)
 	if len(args) > 0 && args[0] == "ambient" {
 		if err := p.SetVolumePercent(50); err != nil {
@@ -71,7 +84,8 @@ func NewPlayCommand(p *player.Players, log zerolog.Logger) Command {
 				Description: "This command will start playing the queue. " +
 					"It has no effect if the player is already " +
 					"active.\nThe bot will join the vocal channel when playing " +
-					"starts.\n\n`ambient` can be passed as an extra argument " +
+					"starts, or your own vocal channel if the guild is set up to " +
+					"follow the caller.\n\n`ambient` can be passed as an extra argument " +
 					"to play in ambient mode with a lower volume.",
 				Examples: []Example{
 					{Command: "play", Explanation: "Start playing"},
@@ -182,6 +196,7 @@ func NewPauseCommand(p *player.Players, log zerolog.Logger) Command {
 
 type skip struct {
 	BaseCommand
+	Access *acl.ACL
 }
 
 func (c *skip) Handler(s *discordgo.Session, m *discordgo.Message, args []string) {
@@ -191,32 +206,72 @@ func (c *skip) Handler(s *discordgo.Session, m *discordgo.Message, args []string
 		return
 	}
 
-	p.Skip()
-	msg := fmt.Sprintf("⏭️ <@%s> skipped the currently playing track", m.Author.ID)
+	// Privileged users can always skip right away
+	ok, err := c.Access.Check(s, m, acl.Privileged, acl.Anywhere)
+	if err != nil {
+		c.log.Err(err).Msg("unable to check acl")
+		return
+	}
+	if ok {
+		p.Skip()
+		msg := fmt.Sprintf("⏭️ <@%s> skipped the currently playing track", m.Author.ID)
+		if err := message.SendReply(s, m, "", msg, ""); err != nil {
+			c.log.Err(err).Msg("unable to send reply")
+		}
+		return
+	}
+
+	if p.GuildConf().VoteSkip == 0 {
+		msg := fmt.Sprintf("You do not have permission to do that.\n**%s**", acl.RestrictionString(acl.Anywhere, acl.Privileged))
+		message.SendShortTimedNotice(s, m, msg, c.log)
+		return
+	}
+
+	votes, needed, err := p.VoteSkip(m.Author.ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, player.ErrNotPlaying):
+			message.SendShortTimedNotice(s, m, "No track is currently playing", c.log)
+		case errors.Is(err, player.ErrNotListening):
+			message.SendShortTimedNotice(s, m, "You need to be listening in the voice channel to vote", c.log)
+		default:
+			c.log.Err(err).Msg("unable to vote")
+		}
+		return
+	}
+
+	msg := fmt.Sprintf("🗳️ <@%s> voted to skip, **%d/%d** votes to skip", m.Author.ID, votes, needed)
+	if votes >= needed {
+		msg = fmt.Sprintf("⏭️ Skipped the currently playing track with **%d/%d** votes", votes, needed)
+	}
 	if err := message.SendReply(s, m, "", msg, ""); err != nil {
 		c.log.Err(err).Msg("unable to send reply")
 	}
 }
 
-func NewSkipCommand(p *player.Players, log zerolog.Logger) Command {
+func NewSkipCommand(p *player.Players, log zerolog.Logger, a *acl.ACL) Command {
 	cmd := "skip"
 	return &skip{
-		BaseCommand{
+		BaseCommand: BaseCommand{
 			ChannelRestriction: acl.Anywhere,
-			RoleRestriction:    acl.Privileged,
+			RoleRestriction:    acl.Anyone,
 			Options: Options{
 				ArgsRequired:      false,
 				DeleteUserMessage: true,
 			},
 			Long: cmd,
 			Help: Help{
-				Usage:       cmd,
-				ShortDesc:   "Skip the currently playing track",
-				Description: "This command can be used to skip tracks at will.",
+				Usage:     cmd,
+				ShortDesc: "Skip the currently playing track",
+				Description: "This command can be used to skip tracks at will. " +
+					"When vote skipping is enabled, other users vote to skip " +
+					"the track instead, and it's skipped once enough listeners " +
+					"in the voice channel voted. Votes are reset on each track.",
 			},
 			Players: p,
 			log:     log.With().Str("command", cmd).Logger(),
 		},
+		Access: a,
 	}
 }
 
@@ -2,6 +2,8 @@ package commands
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/depado/fox/acl"
@@ -13,6 +15,42 @@ import (
 
 type queue struct {
 	BaseCommand
+	Access *acl.ACL
+}
+
+func (c *queue) move(s *discordgo.Session, m *discordgo.Message, p *player.Player, args []string) {
+	if !requirePrivileged(s, m, c.Access, c.log) {
+		return
+	}
+
+	if len(args) != 2 {
+		message.SendShortTimedNotice(s, m, "Use `queue move <from> <to>` with the positions of the tracks in queue", c.log)
+		return
+	}
+	from, err := strconv.Atoi(args[0])
+	if err != nil {
+		message.SendShortTimedNotice(s, m, "The positions must be numbers", c.log)
+		return
+	}
+	to, err := strconv.Atoi(args[1])
+	if err != nil {
+		message.SendShortTimedNotice(s, m, "The positions must be numbers", c.log)
+		return
+	}
+
+	t, err := p.Queue.Move(from, to)
+	if err != nil {
+		message.SendShortTimedNotice(s, m, "There is no track at one of these positions in queue", c.log)
+		return
+	}
+	msg := fmt.Sprintf("↕️ <@%s> moved %s to position %d", m.Author.ID, strings.TrimSpace(t.MarkdownLink()), to)
+	if p.GuildConf().FairQueue {
+		// The rounds are laid out again, the track may not stay at this position
+		msg = fmt.Sprintf("↕️ <@%s> moved %s, the fair queue keeps the rounds of requesters", m.Author.ID, strings.TrimSpace(t.MarkdownLink()))
+	}
+	if err := message.SendReply(s, m, "", msg, ""); err != nil {
+		c.log.Err(err).Msg("unable to send reply")
+	}
 }
 
 func (c *queue) Handler(s *discordgo.Session, m *discordgo.Message, args []string) {
@@ -38,15 +76,90 @@ func (c *queue) Handler(s *discordgo.Session, m *discordgo.Message, args []strin
 		return
 	}
 
-	if _, err := s.ChannelMessageSendEmbed(m.ChannelID, p.Queue.GenerateQueueEmbed()); err != nil {
+	if len(args) > 0 && (args[0] == "move" || args[0] == "mv") {
+		c.move(s, m, p, args[1:])
+		return
+	}
+
+	page := 1
+	if len(args) > 0 {
+		var err error
+		if page, err = strconv.Atoi(args[0]); err != nil || page < 1 {
+			message.SendShortTimedNotice(s, m, "The page must be a number starting at 1", c.log)
+			return
+		}
+	}
+	page = min(page, p.Queue.Pages())
+	_, err := s.ChannelMessageSendComplex(m.ChannelID, &discordgo.MessageSend{
+		Embeds:     []*discordgo.MessageEmbed{p.Queue.GenerateQueueEmbed(page)},
+		Components: queueComponents(page, p.Queue.Pages()),
+	})
+	if err != nil {
 		c.log.Err(err).Msg("unable to send embed")
 	}
 }
 
-func NewQueueCommand(p *player.Players, log zerolog.Logger) Command {
+// queueComponents returns the buttons to browse the pages of the queue, none
+// if there is a single page. The custom ID of each button holds the page it
+// leads to.
+func queueComponents(page, pages int) []discordgo.MessageComponent {
+	if pages < 2 {
+		return []discordgo.MessageComponent{}
+	}
+	button := func(id, emoji string, to int) discordgo.Button {
+		return discordgo.Button{
+			Style:    discordgo.SecondaryButton,
+			Emoji:    &discordgo.ComponentEmoji{Name: emoji},
+			CustomID: fmt.Sprintf("queue:%s:%d", id, to),
+			Disabled: to == page,
+		}
+	}
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			button("first", "⏮️", 1),
+			button("prev", "◀️", max(page-1, 1)),
+			button("next", "▶️", min(page+1, pages)),
+			button("last", "⏭️", pages),
+		}},
+	}
+}
+
+// Interaction will edit the queue message in place to display the page of the
+// clicked button.
+func (c *queue) Interaction(s *discordgo.Session, i *discordgo.InteractionCreate, args []string) {
+	p := c.Players.GetPlayer(i.GuildID)
+	if p == nil {
+		c.log.Error().Msg("no player associated to guild ID")
+		return
+	}
+	if len(args) != 2 {
+		c.log.Error().Strs("args", args).Msg("unexpected queue interaction")
+		return
+	}
+	page, err := strconv.Atoi(args[1])
+	if err != nil {
+		c.log.Err(err).Msg("invalid queue page")
+		return
+	}
+
+	// The queue may have shrunk since the message was sent
+	page = min(page, p.Queue.Pages())
+	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     []*discordgo.MessageEmbed{p.Queue.GenerateQueueEmbed(page)},
+			Components: queueComponents(page, p.Queue.Pages()),
+		},
+	})
+	if err != nil {
+		c.log.Err(err).Msg("unable to update queue message")
+	}
+}
+
+func NewQueueCommand(p *player.Players, log zerolog.Logger, a *acl.ACL) Command {
 	cmd := "queue"
 	return &queue{
-		BaseCommand{
+		BaseCommand: BaseCommand{
 			ChannelRestriction: acl.Music,
 			RoleRestriction:    acl.Anyone,
 			Options: Options{
@@ -58,17 +171,26 @@ func NewQueueCommand(p *player.Players, log zerolog.Logger) Command {
 			Help: Help{
 				Usage:     cmd,
 				ShortDesc: "Display or modify the queue",
-				Description: "This command will display the current queue. " +
+				Description: "This command will display the current queue, " +
+					"with buttons to browse its pages. " +
 					"It can also shuffle the current queue if the `shuffle` " +
-					"argument is passed.",
+					"argument is passed, and privileged users can move a " +
+					"track to another position, positions starting at 1 " +
+					"with the next track. With the fair queue enabled, the " +
+					"tracks are then laid out in rounds again: the moved " +
+					"track comes first among its requester's tracks, and " +
+					"its requester's turn moves accordingly.",
 				Examples: []Example{
 					{Command: "queue", Explanation: "Display the queue"},
+					{Command: "queue 3", Explanation: "Display the third page of the queue"},
 					{Command: "queue shuffle", Explanation: "Shuffle the queue"},
+					{Command: "queue move 5 1", Explanation: "Move the fifth track so that it plays next"},
 					{Command: "q", Explanation: "Display the queue with the alias"},
 				},
 			},
 			Players: p,
 			log:     log.With().Str("command", cmd).Logger(),
 		},
+		Access: a,
 	}
 }
@@ -3,6 +3,7 @@ package commands
 import (
 	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/depado/fox/acl"
@@ -12,8 +13,27 @@ import (
 	"github.com/rs/zerolog"
 )
 
+// parseRange parses either a single position (3) or a range of positions
+// (3-10) in queue
+func parseRange(v string) (int, int, error) {
+	a, b, isRange := strings.Cut(v, "-")
+	from, err := strconv.Atoi(a)
+	if err != nil || from < 1 {
+		return 0, 0, fmt.Errorf("invalid position")
+	}
+	if !isRange {
+		return from, from, nil
+	}
+	to, err := strconv.Atoi(b)
+	if err != nil || to < from {
+		return 0, 0, fmt.Errorf("invalid range")
+	}
+	return from, to, nil
+}
+
 type remove struct {
 	BaseCommand
+	Access *acl.ACL
 }
 
 func (c *remove) Handler(s *discordgo.Session, m *discordgo.Message, args []string) {
@@ -23,36 +43,75 @@ func (c *remove) Handler(s *discordgo.Session, m *discordgo.Message, args []stri
 		return
 	}
 
-	if len(args) > 0 && (args[0] == "all" || args[0] == "a" || args[0] == "-a") {
-		p.Queue.Clear()
-		msg := fmt.Sprintf("🚮 The queue was reset by <@%s>", m.Author.ID)
+	// Anyone can remove their own tracks
+	if args[0] == "mine" {
+		n := p.Queue.RemoveUser(m.Author.ID)
+		msg := fmt.Sprintf("🚮 <@%s> removed their **%d** tracks from the queue", m.Author.ID, n)
 		if err := message.SendReply(s, m, "", msg, ""); err != nil {
 			c.log.Err(err).Msg("unable to send reply")
 		}
 		return
 	}
 
-	n, err := strconv.Atoi(args[0])
-	if err != nil || n < 1 {
-		if err := message.SendTimedReply(s, m, "", "The argument is invalid", "", 5*time.Second); err != nil {
-			c.log.Err(err).Msg("unable to send timed reply")
-		}
+	if !requirePrivileged(s, m, c.Access, c.log) {
 		return
 	}
 
-	p.Queue.RemoveN(n)
-	msg := fmt.Sprintf("🚮 The next %d tracks in queue were removed by <@%s>", n, m.Author.ID)
+	var msg string
+	switch {
+	case args[0] == "all" || args[0] == "a" || args[0] == "-a":
+		p.Queue.Clear()
+		msg = fmt.Sprintf("🚮 The queue was reset by <@%s>", m.Author.ID)
+	case args[0] == "next" && len(args) > 1:
+		n, err := strconv.Atoi(args[1])
+		if err != nil || n < 1 {
+			c.invalid(s, m)
+			return
+		}
+		p.Queue.RemoveN(n)
+		msg = fmt.Sprintf("🚮 The next %d tracks in queue were removed by <@%s>", n, m.Author.ID)
+	case len(m.Mentions) > 0:
+		u := m.Mentions[0]
+		n := p.Queue.RemoveUser(u.ID)
+		msg = fmt.Sprintf("🚮 <@%s> removed the **%d** tracks added by <@%s>", m.Author.ID, n, u.ID)
+	default:
+		from, to, err := parseRange(args[0])
+		if err != nil {
+			c.invalid(s, m)
+			return
+		}
+		n, err := p.Queue.RemoveRange(from, to)
+		if err != nil {
+			message.SendShortTimedNotice(s, m, fmt.Sprintf("There is no track at position %d in queue", from), c.log)
+			return
+		}
+		msg = fmt.Sprintf("🚮 <@%s> removed **%d** tracks from the queue", m.Author.ID, n)
+		if from == to {
+			msg = fmt.Sprintf("🚮 <@%s> removed the track at position %d in queue", m.Author.ID, from)
+		}
+		// A single number used to remove as many tracks, it's now a position
+		if from == to && from > 1 && !strings.Contains(args[0], "-") {
+			msg += fmt.Sprintf("\nUse `rm next %d` to remove the next %d tracks", from, from)
+		}
+	}
+
 	if err := message.SendReply(s, m, "", msg, ""); err != nil {
 		c.log.Err(err).Msg("unable to send reply")
 	}
 }
 
-func NewRemoveCommand(p *player.Players, log zerolog.Logger) Command {
+func (c *remove) invalid(s *discordgo.Session, m *discordgo.Message) {
+	if err := message.SendTimedReply(s, m, "", "The argument is invalid", "", 5*time.Second); err != nil {
+		c.log.Err(err).Msg("unable to send timed reply")
+	}
+}
+
+func NewRemoveCommand(p *player.Players, log zerolog.Logger, a *acl.ACL) Command {
 	cmd := "remove"
 	return &remove{
-		BaseCommand{
+		BaseCommand: BaseCommand{
 			ChannelRestriction: acl.Music,
-			RoleRestriction:    acl.Privileged,
+			RoleRestriction:    acl.Anyone,
 			Options: Options{
 				ArgsRequired:      true,
 				DeleteUserMessage: true,
@@ -61,17 +120,28 @@ func NewRemoveCommand(p *player.Players, log zerolog.Logger) Command {
 			Aliases: []string{"rm"},
 			Help: Help{
 				Usage:     cmd,
-				ShortDesc: "Clear the queue",
+				ShortDesc: "Remove tracks from the queue",
 				Description: "This command can be used to remove all the " +
-					"tracks or a certain number of tracks in queue.",
+					"tracks, the tracks at given positions, or the tracks " +
+					"added by someone. Positions start at 1 with the next " +
+					"track, the track being played is never removed. " +
+					"A single number is a position: `rm 3` removes the third " +
+					"track only, use `rm next 3` to remove the next three. " +
+					"Everyone can remove their own tracks, the rest is " +
+					"reserved to privileged users.",
 				Examples: []Example{
 					{Command: "remove all", Explanation: "Remove all tracks in queue"},
 					{Command: "rm -a", Explanation: "Remove all tracks in queue"},
-					{Command: "rm 10", Explanation: "Remove the next 10 tracks in queue"},
+					{Command: "rm 3", Explanation: "Remove the track at position 3"},
+					{Command: "rm 3-10", Explanation: "Remove the tracks from position 3 to 10"},
+					{Command: "rm next 10", Explanation: "Remove the next 10 tracks in queue"},
+					{Command: "rm @someone", Explanation: "Remove the tracks added by someone"},
+					{Command: "rm mine", Explanation: "Remove the tracks you added"},
 				},
 			},
 			Players: p,
 			log:     log.With().Str("command", cmd).Logger(),
 		},
+		Access: a,
 	}
 }
@@ -0,0 +1,215 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/rs/zerolog"
+
+	"github.com/depado/fox/acl"
+	"github.com/depado/fox/message"
+	"github.com/depado/fox/models"
+	"github.com/depado/fox/player"
+	"github.com/depado/fox/scheduler"
+	"github.com/depado/fox/storage"
+)
+
+type schedule struct {
+	BaseCommand
+	Storage *storage.BoltStorage
+}
+
+// parseSchedule parses the arguments of `schedule add`: a cron expression
+// followed by the playlist URL and options.
+func parseSchedule(args []string) (*models.Schedule, error) {
+	i := 0
+	for i < len(args) && !strings.HasPrefix(strings.Trim(args[i], "<>"), "https://") {
+		i++
+	}
+	if i == len(args) {
+		return nil, fmt.Errorf("missing playlist URL")
+	}
+	url := strings.Trim(args[i], "<>")
+	if !strings.HasPrefix(url, "https://soundcloud.com") {
+		return nil, fmt.Errorf("this doesn't look like a SoundCloud URL")
+	}
+
+	sc := &models.Schedule{Cron: strings.Trim(strings.Join(args[:i], " "), `"`), URL: url}
+	if _, err := scheduler.ParseCron(sc.Cron); err != nil {
+		return nil, fmt.Errorf("invalid cron expression: %w", err)
+	}
+
+	for _, o := range args[i+1:] {
+		k, v, _ := strings.Cut(o, "=")
+		switch k {
+		case "shuffle":
+			sc.Shuffle = true
+		case "volume":
+			n, err := strconv.Atoi(strings.TrimSuffix(v, "%"))
+			if err != nil || n < 1 || n > 200 {
+				return nil, fmt.Errorf("the volume must be between 1 and 200")
+			}
+			sc.Volume = n
+		case "duration":
+			d, err := time.ParseDuration(v)
+			if err != nil || d < time.Minute {
+				return nil, fmt.Errorf("invalid duration, use something like `2h` or `90m`")
+			}
+			sc.Duration = d
+		default:
+			return nil, fmt.Errorf("unknown option `%s`", k)
+		}
+	}
+	return sc, nil
+}
+
+func (c *schedule) list(s *discordgo.Session, m *discordgo.Message, gconf *models.Conf) {
+	ss, err := c.Storage.GetSchedules(m.GuildID)
+	if err != nil {
+		c.log.Err(err).Msg("unable to get schedules")
+		return
+	}
+
+	loc := scheduler.Location(gconf)
+	var body string
+	if len(ss) == 0 {
+		body = "There is no scheduled session"
+	}
+	for _, sc := range ss {
+		body += fmt.Sprintf("`#%d` `%s` • [playlist](%s)", sc.ID, sc.Cron, sc.URL)
+		if cr, err := scheduler.ParseCron(sc.Cron); err == nil {
+			if n := cr.Next(time.Now().In(loc)); !n.IsZero() {
+				body += fmt.Sprintf(" • next <t:%d:R>", n.Unix())
+			}
+		}
+		var opts []string
+		if sc.Shuffle {
+			opts = append(opts, "🔀 shuffled")
+		}
+		if sc.Volume > 0 {
+			opts = append(opts, fmt.Sprintf("🔊 %d%%", sc.Volume))
+		}
+		if sc.Duration > 0 {
+			opts = append(opts, fmt.Sprintf("⏱️ %s", sc.Duration))
+		}
+		if len(opts) > 0 {
+			body += "\n" + strings.Join(opts, " • ")
+		}
+		body += "\n"
+	}
+
+	e := &discordgo.MessageEmbed{
+		Title:       "Scheduled Sessions",
+		Description: body,
+		Color:       0xff5500,
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: "Timezone: " + loc.String(),
+		},
+	}
+	if _, err := s.ChannelMessageSendEmbed(m.ChannelID, e); err != nil {
+		c.log.Err(err).Msg("unable to send embed")
+	}
+}
+
+func (c *schedule) Handler(s *discordgo.Session, m *discordgo.Message, args []string) {
+	gconf, err := c.Storage.GetGuildConf(m.GuildID)
+	if err != nil {
+		c.log.Err(err).Msg("unable to fetch guild conf")
+		return
+	}
+
+	if len(args) == 0 || args[0] == "list" || args[0] == "ls" {
+		c.list(s, m, gconf)
+		return
+	}
+
+	switch args[0] {
+	case "add", "a":
+		sc, err := parseSchedule(args[1:])
+		if err != nil {
+			message.SendShortTimedNotice(s, m, "Unable to schedule this session: "+err.Error(), c.log)
+			return
+		}
+		sc.CreatedBy = m.Author.ID
+		if err := c.Storage.AddSchedule(m.GuildID, sc); err != nil {
+			c.log.Err(err).Msg("unable to add schedule")
+			return
+		}
+		msg := fmt.Sprintf("📅 <@%s> scheduled session `#%d`", m.Author.ID, sc.ID)
+		if cr, err := scheduler.ParseCron(sc.Cron); err == nil {
+			if n := cr.Next(time.Now().In(scheduler.Location(gconf))); !n.IsZero() {
+				msg += fmt.Sprintf(", it will start <t:%d:F>", n.Unix())
+			}
+		}
+		if err := message.SendReply(s, m, "", msg, ""); err != nil {
+			c.log.Err(err).Msg("unable to send reply")
+		}
+	case "remove", "rm":
+		if len(args) < 2 {
+			message.SendShortTimedNotice(s, m, "Which session should I remove? Give me its number", c.log)
+			return
+		}
+		id, err := strconv.Atoi(strings.TrimPrefix(args[1], "#"))
+		if err != nil {
+			message.SendShortTimedNotice(s, m, "The argument is invalid", c.log)
+			return
+		}
+		if err := c.Storage.RemoveSchedule(m.GuildID, id); err != nil {
+			if errors.Is(err, storage.ErrScheduleNotFound) {
+				message.SendShortTimedNotice(s, m, fmt.Sprintf("There is no scheduled session #%d", id), c.log)
+				return
+			}
+			c.log.Err(err).Msg("unable to remove schedule")
+			return
+		}
+		msg := fmt.Sprintf("📅 <@%s> removed scheduled session `#%d`", m.Author.ID, id)
+		if err := message.SendReply(s, m, "", msg, ""); err != nil {
+			c.log.Err(err).Msg("unable to send reply")
+		}
+	default:
+		message.SendShortTimedNotice(s, m, "Unknown subcommand, use `add`, `list` or `remove`", c.log)
+	}
+}
+
+func NewScheduleCommand(p *player.Players, log zerolog.Logger, storage *storage.BoltStorage) Command {
+	cmd := "schedule"
+	return &schedule{
+		BaseCommand: BaseCommand{
+			ChannelRestriction: acl.Anywhere,
+			RoleRestriction:    acl.Admin,
+			Options: Options{
+				ArgsRequired:      false,
+				DeleteUserMessage: true,
+			},
+			Long:    cmd,
+			Aliases: []string{"sched"},
+			SubCommands: []SubCommand{
+				{Long: "add", Aliases: []string{"a"}, Arg: "cron url [options]", Description: "Schedule a playlist"},
+				{Long: "list", Aliases: []string{"ls"}, Description: "List the scheduled sessions"},
+				{Long: "remove", Aliases: []string{"rm"}, Arg: "number", Description: "Remove a scheduled session"},
+			},
+			Help: Help{
+				Usage:     cmd,
+				ShortDesc: "Schedule recurring playback sessions",
+				Description: "This command schedules playlists to be played at " +
+					"recurring times, using a cron expression (minute, hour, day " +
+					"of month, month, day of week) in the guild's timezone set " +
+					"with `setup timezone`. Options are `shuffle`, `volume=N` " +
+					"and `duration=D` to stop playback after a while.",
+				Examples: []Example{
+					{Command: "schedule add 0 21 * * 5 <url>", Explanation: "Play the playlist every Friday at 9pm"},
+					{Command: "schedule add 30 20 * * 1-5 <url> shuffle volume=50 duration=2h", Explanation: "Play the shuffled playlist on weekdays at 8:30pm at half volume for two hours"},
+					{Command: "schedule list", Explanation: "List the scheduled sessions"},
+					{Command: "schedule remove 2", Explanation: "Remove the session #2"},
+				},
+			},
+			Players: p,
+			log:     log.With().Str("command", cmd).Logger(),
+		},
+		Storage: storage,
+	}
+}
@@ -0,0 +1,334 @@
+package commands
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/hako/durafmt"
+	"github.com/rs/zerolog"
+
+	"github.com/depado/fox/acl"
+	"github.com/depado/fox/message"
+	"github.com/depado/fox/player"
+	"github.com/depado/fox/soundcloud"
+	"github.com/depado/fox/tracks"
+)
+
+const (
+	// searchTracks is the number of tracks shown in the results
+	searchTracks = 5
+	// searchPlaylists is the number of playlists shown in the results
+	searchPlaylists = 3
+	// searchTimeout is how long the results can be picked from
+	searchTimeout = time.Minute
+)
+
+// pendingSearch holds the results shown to a user until they pick one
+type pendingSearch struct {
+	// m is the message of the user who searched, the picked result is added
+	// on their behalf
+	m       *discordgo.Message
+	results []soundcloud.SearchResult
+	// message is the ID of the message displaying the results
+	message string
+	timer   *time.Timer
+}
+
+// searches holds the pending searches, a user having at most one per channel
+type searches struct {
+	sync.Mutex
+	m map[string]*pendingSearch
+}
+
+func searchKey(channel, user string) string {
+	return channel + ":" + user
+}
+
+type search struct {
+	BaseCommand
+	sp      *soundcloud.SoundCloudProvider
+	pending searches
+}
+
+func (c *search) Handler(s *discordgo.Session, m *discordgo.Message, args []string) {
+	if c.Players.GetPlayer(m.GuildID) == nil {
+		c.log.Error().Msg("no player associated to guild ID")
+		return
+	}
+
+	query := strings.Join(args, " ")
+	results, err := c.sp.Search(query, searchTracks, searchPlaylists)
+	if err != nil {
+		c.log.Err(err).Str("query", query).Msg("unable to search")
+		message.SendShortTimedNotice(s, m, "I couldn't search SoundCloud right now, try again later", c.log)
+		return
+	}
+	if len(results) == 0 {
+		message.SendShortTimedNotice(s, m, "I couldn't find anything matching your search", c.log)
+		return
+	}
+
+	var body string
+	for i, r := range results {
+		if r.Playlist() {
+			body += fmt.Sprintf("`%d.` 💿 %s\n`%d tracks` • `%s`\n", i+1, r.MarkdownLink(), r.Tracks, durafmt.Parse(r.Duration).LimitFirstN(2))
+		} else {
+			body += fmt.Sprintf("`%d.` 🎵 %s\n`%s`\n", i+1, r.MarkdownLink(), durafmt.Parse(r.Duration).LimitFirstN(2))
+		}
+	}
+	sent, err := s.ChannelMessageSendComplex(m.ChannelID, &discordgo.MessageSend{
+		Embeds: []*discordgo.MessageEmbed{{
+			Title:       "🔎 " + query,
+			Description: body,
+			Color:       0xff5500,
+			Footer: &discordgo.MessageEmbedFooter{
+				IconURL: m.Author.AvatarURL(""),
+				Text:    "Pick a result with the buttons or by replying with its number",
+			},
+		}},
+		Components: searchComponents(len(results)),
+	})
+	if err != nil {
+		c.log.Err(err).Msg("unable to send search results")
+		return
+	}
+
+	key := searchKey(m.ChannelID, m.Author.ID)
+	ps := &pendingSearch{m: m, results: results, message: sent.ID}
+	ps.timer = time.AfterFunc(searchTimeout, func() {
+		if c.take(key, ps.message) != nil {
+			c.finish(s, ps, &discordgo.MessageEmbed{Title: "This search expired", Color: 0xff5500})
+		}
+	})
+
+	c.pending.Lock()
+	old := c.pending.m[key]
+	c.pending.m[key] = ps
+	c.pending.Unlock()
+	// Only the last search of a user can be picked from
+	if old != nil {
+		old.timer.Stop()
+		c.finish(s, old, &discordgo.MessageEmbed{Title: "This search was replaced by a new one", Color: 0xff5500})
+	}
+}
+
+// searchComponents returns a numbered button for each result, five per row,
+// followed by a button to cancel the search.
+func searchComponents(n int) []discordgo.MessageComponent {
+	var rows []discordgo.MessageComponent
+	var row []discordgo.MessageComponent
+	for i := 0; i <= n; i++ {
+		b := discordgo.Button{
+			Style:    discordgo.PrimaryButton,
+			Label:    strconv.Itoa(i + 1),
+			CustomID: fmt.Sprintf("search:%d", i+1),
+		}
+		if i == n {
+			b = discordgo.Button{
+				Style:    discordgo.SecondaryButton,
+				Emoji:    &discordgo.ComponentEmoji{Name: "✖️"},
+				CustomID: "search:cancel",
+			}
+		}
+		row = append(row, b)
+		if len(row) == 5 || i == n {
+			rows = append(rows, discordgo.ActionsRow{Components: row})
+			row = nil
+		}
+	}
+	return rows
+}
+
+// take will remove the pending search of the key and return it, if it's
+// displayed in the given message.
+func (c *search) take(key, msg string) *pendingSearch {
+	c.pending.Lock()
+	defer c.pending.Unlock()
+
+	ps, ok := c.pending.m[key]
+	if !ok || ps.message != msg {
+		return nil
+	}
+	delete(c.pending.m, key)
+	ps.timer.Stop()
+	return ps
+}
+
+// searcher returns the ID of the user whose pending search is displayed in the
+// given message, if any.
+func (c *search) searcher(msg string) (string, bool) {
+	c.pending.Lock()
+	defer c.pending.Unlock()
+
+	for _, ps := range c.pending.m {
+		if ps.message == msg {
+			return ps.m.Author.ID, true
+		}
+	}
+	return "", false
+}
+
+// finish will replace the results message with the given embed, removing the
+// buttons.
+func (c *search) finish(s *discordgo.Session, ps *pendingSearch, e *discordgo.MessageEmbed) {
+	embeds := []*discordgo.MessageEmbed{e}
+	components := []discordgo.MessageComponent{}
+	_, err := s.ChannelMessageEditComplex(&discordgo.MessageEdit{
+		ID:         ps.message,
+		Channel:    ps.m.ChannelID,
+		Embeds:     &embeds,
+		Components: &components,
+	})
+	if err != nil {
+		c.log.Err(err).Msg("unable to edit search results")
+	}
+}
+
+// pick will add the n-th result, starting at 1, to the end of queue on behalf
+// of the user who searched, and return the embed describing what was added.
+func (c *search) pick(ps *pendingSearch, n int) *discordgo.MessageEmbed {
+	p := c.Players.GetPlayer(ps.m.GuildID)
+	if p == nil {
+		c.log.Error().Msg("no player associated to guild ID")
+		return &discordgo.MessageEmbed{Title: "I couldn't add this result", Color: 0xff5500}
+	}
+	r := ps.results[n-1]
+
+	var tr tracks.Tracks
+	var e *discordgo.MessageEmbed
+	var err error
+	if r.Playlist() {
+		tr, e, err = c.sp.GetPlaylist(r.URL, ps.m)
+	} else {
+		var t tracks.Track
+		if t, e, err = c.sp.GetTrack(r.URL, ps.m); err == nil {
+			tr = tracks.Tracks{t}
+		}
+	}
+	if err != nil {
+		c.log.Err(err).Str("url", r.URL).Msg("unable to get search result")
+		return &discordgo.MessageEmbed{Title: "I couldn't add this result", Description: r.MarkdownLink(), Color: 0xff5500}
+	}
+
+	a := p.Queue.AppendWithin(p.GuildConf().Limits, tr...)
+	desc := fmt.Sprintf("Added **%d** tracks to end of queue", a.Added)
+	if !r.Playlist() {
+		desc = "Added one track to end of queue"
+	}
+	return admissionEmbed(e, a, desc)
+}
+
+// Interaction handles the buttons of the results, which can only be used by
+// the user who searched.
+func (c *search) Interaction(s *discordgo.Session, i *discordgo.InteractionCreate, args []string) {
+	if len(args) != 1 || i.Member == nil {
+		return
+	}
+	key := searchKey(i.ChannelID, i.Member.User.ID)
+
+	n, err := strconv.Atoi(args[0])
+	if args[0] != "cancel" && (err != nil || n < 1) {
+		c.log.Error().Strs("args", args).Msg("unexpected search interaction")
+		return
+	}
+
+	c.pending.Lock()
+	ps, ok := c.pending.m[key]
+	c.pending.Unlock()
+	if ok && ps.message == i.Message.ID && n > len(ps.results) {
+		c.log.Error().Strs("args", args).Msg("unexpected search interaction")
+		return
+	}
+	if ps = c.take(key, i.Message.ID); ps == nil {
+		msg := "This search expired or was replaced by a new one"
+		if u, ok := c.searcher(i.Message.ID); ok && u != i.Member.User.ID {
+			msg = "Only the person who searched can pick a result"
+		}
+		err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: msg,
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+		if err != nil {
+			c.log.Err(err).Msg("unable to respond to interaction")
+		}
+		return
+	}
+
+	// Fetching the result can take longer than Discord waits for a response
+	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredMessageUpdate,
+	})
+	if err != nil {
+		c.log.Err(err).Msg("unable to respond to interaction")
+	}
+	if args[0] == "cancel" {
+		if err := s.ChannelMessageDelete(i.ChannelID, ps.message); err != nil {
+			c.log.Err(err).Msg("unable to delete search results")
+		}
+		return
+	}
+	c.finish(s, ps, c.pick(ps, n))
+}
+
+// FollowUp picks a result when the user who searched replies with its number.
+func (c *search) FollowUp(s *discordgo.Session, m *discordgo.Message) bool {
+	n, err := strconv.Atoi(strings.TrimSpace(m.Content))
+	if err != nil {
+		return false
+	}
+	key := searchKey(m.ChannelID, m.Author.ID)
+
+	c.pending.Lock()
+	ps, ok := c.pending.m[key]
+	c.pending.Unlock()
+	if !ok || n < 1 || n > len(ps.results) {
+		return false
+	}
+	if ps = c.take(key, ps.message); ps == nil {
+		return false
+	}
+
+	message.Delete(s, m, c.log)
+	c.finish(s, ps, c.pick(ps, n))
+	return true
+}
+
+func NewSearchCommand(p *player.Players, log zerolog.Logger, sp *soundcloud.SoundCloudProvider) Command {
+	cmd := "search"
+	return &search{
+		sp:      sp,
+		pending: searches{m: make(map[string]*pendingSearch)},
+		BaseCommand: BaseCommand{
+			ChannelRestriction: acl.Music,
+			RoleRestriction:    acl.Anyone,
+			Options: Options{
+				ArgsRequired:      true,
+				DeleteUserMessage: true,
+			},
+			Long:    cmd,
+			Aliases: []string{"find"},
+			Help: Help{
+				Usage:     cmd,
+				ShortDesc: "Search SoundCloud for tracks and playlists",
+				Description: "This command searches SoundCloud and displays the " +
+					"top tracks and playlists. Pick one with the buttons or by " +
+					"replying with its number to add it to the end of the " +
+					"queue. The `add` command also searches when it's not given " +
+					"a URL.",
+				Examples: []Example{
+					{Command: "search daft punk", Explanation: "Search for Daft Punk tracks and playlists"},
+					{Command: "add daft punk", Explanation: "Same using the add command"},
+				},
+			},
+			Players: p,
+			log:     log.With().Str("command", cmd).Logger(),
+		},
+	}
+}
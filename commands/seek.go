@@ -0,0 +1,132 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/rs/zerolog"
+
+	"github.com/depado/fox/acl"
+	"github.com/depado/fox/message"
+	"github.com/depado/fox/player"
+)
+
+// parsePosition parses either a clock notation (1:30, 1:02:03), a number of
+// seconds (90) or a Go duration (1m30s). The returned boolean indicates
+// whether the position is relative to the current one, which is the case when
+// the value starts with a sign.
+func parsePosition(v string) (time.Duration, bool, error) {
+	var rel bool
+	var neg bool
+
+	if strings.HasPrefix(v, "+") || strings.HasPrefix(v, "-") {
+		rel = true
+		neg = v[0] == '-'
+		v = v[1:]
+	}
+
+	var d time.Duration
+	if strings.Contains(v, ":") {
+		for _, p := range strings.Split(v, ":") {
+			n, err := strconv.Atoi(p)
+			if err != nil || n < 0 {
+				return 0, false, fmt.Errorf("invalid clock notation")
+			}
+			d = d*60 + time.Duration(n)*time.Second
+		}
+	} else if n, err := strconv.Atoi(v); err == nil {
+		d = time.Duration(n) * time.Second
+	} else if d, err = time.ParseDuration(v); err != nil {
+		return 0, false, fmt.Errorf("invalid duration: %w", err)
+	}
+
+	if d < 0 {
+		return 0, false, fmt.Errorf("negative position")
+	}
+	if neg {
+		d = -d
+	}
+	return d, rel, nil
+}
+
+type seek struct {
+	BaseCommand
+}
+
+func (c *seek) Handler(s *discordgo.Session, m *discordgo.Message, args []string) {
+	p := c.Players.GetPlayer(m.GuildID)
+	if p == nil {
+		c.log.Error().Msg("no player associated to guild ID")
+		return
+	}
+
+	if !p.Playing() {
+		message.SendShortTimedNotice(s, m, "No track is currently playing", c.log)
+		return
+	}
+
+	d, rel, err := parsePosition(args[0])
+	if err != nil {
+		message.SendShortTimedNotice(s, m, "Invalid position, use `1:30`, `90`, `+30s` or `-10s`", c.log)
+		return
+	}
+	if rel {
+		d += p.Position()
+		if d < 0 {
+			d = 0
+		}
+	}
+
+	if err := p.Seek(d); err != nil {
+		switch {
+		case errors.Is(err, player.ErrSeekOutOfRange):
+			message.SendShortTimedNotice(s, m, "This position is beyond the end of the track", c.log)
+		case errors.Is(err, player.ErrNotPlaying):
+			message.SendShortTimedNotice(s, m, "No track is currently playing", c.log)
+		default:
+			c.log.Err(err).Msg("unable to seek")
+		}
+		return
+	}
+
+	msg := fmt.Sprintf("⏩ <@%s> moved playback to %s", m.Author.ID, d.Truncate(time.Second))
+	if err := message.SendReply(s, m, "", msg, ""); err != nil {
+		c.log.Err(err).Msg("unable to send reply")
+	}
+}
+
+func NewSeekCommand(p *player.Players, log zerolog.Logger) Command {
+	cmd := "seek"
+	return &seek{
+		BaseCommand{
+			ChannelRestriction: acl.Music,
+			RoleRestriction:    acl.Privileged,
+			Options: Options{
+				ArgsRequired:      true,
+				DeleteUserMessage: true,
+			},
+			Long: cmd,
+			Help: Help{
+				Usage:     cmd,
+				ShortDesc: "Jump to a position in the current track",
+				Description: "This command will move the playback to the given " +
+					"position in the currently playing track. The position can " +
+					"either be absolute or relative to the current position when " +
+					"prefixed with `+` or `-`. If the player is paused, it will " +
+					"stay paused.",
+				Examples: []Example{
+					{Command: "seek 1:30", Explanation: "Jump to 1 minute and 30 seconds"},
+					{Command: "seek 90", Explanation: "Same using seconds"},
+					{Command: "seek +30s", Explanation: "Skip forward 30 seconds"},
+					{Command: "seek -10s", Explanation: "Go back 10 seconds"},
+				},
+			},
+			Players: p,
+			log:     log.With().Str("command", cmd).Logger(),
+		},
+	}
+}
@@ -3,52 +3,259 @@ package commands
 import (
 	"errors"
 	"fmt"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/depado/fox/acl"
 	"github.com/depado/fox/message"
 	"github.com/depado/fox/models"
 	"github.com/depado/fox/player"
-	"github.com/depado/fox/storage"
 	"github.com/bwmarrin/discordgo"
 	"github.com/rs/zerolog"
 )
 
 type setup struct {
 	BaseCommand
-	Storage *storage.BoltStorage
 }
 
-func (c *setup) handleVoiceChannel(s *discordgo.Session, m *discordgo.Message, gconf *models.Conf, value string) {
+func (c *setup) handleVoiceChannel(s *discordgo.Session, m *discordgo.Message, gconf *models.Conf, value string) bool {
 	if err := gconf.SetChannel(s, value, true); err != nil {
 		if errors.Is(err, models.ChannelNotFoundError) {
 			message.SendShortTimedNotice(s, m, "I couldn't find any vocal channel named like this", c.log)
-			return
+			return false
 		}
 		c.log.Err(err).Msg("unable to set voice channel")
-		return
+		return false
 	}
 	message.SendShortTimedNotice(s, m, "Alright, I'll stream the music to this channel from now on", c.log)
+	return true
 }
 
-func (c *setup) handleTextChannel(s *discordgo.Session, m *discordgo.Message, gconf *models.Conf, value string) {
+func (c *setup) handleTextChannel(s *discordgo.Session, m *discordgo.Message, gconf *models.Conf, value string) bool {
 	if err := gconf.SetChannel(s, value, false); err != nil {
 		if errors.Is(err, models.ChannelNotFoundError) {
 			message.SendShortTimedNotice(s, m, "I couldn't find any text channel named like this", c.log)
-			return
+			return false
 		}
-		c.log.Err(err).Msg("unable to set voice channel")
-		return
+		c.log.Err(err).Msg("unable to set text channel")
+		return false
 	}
 	message.SendShortTimedNotice(s, m, fmt.Sprintf("Noted, the music channel is now <#%s>", gconf.TextChannel), c.log)
+	return true
 }
 
-func (c *setup) Handler(s *discordgo.Session, m *discordgo.Message, args []string) {
-	var err error
-	var gconf *models.Conf
+func (c *setup) handleCrossfade(s *discordgo.Session, m *discordgo.Message, gconf *models.Conf, value string) bool {
+	v, err := strconv.Atoi(strings.TrimSuffix(value, "s"))
+	if err != nil || v < 0 || v > 12 {
+		message.SendShortTimedNotice(s, m, "The crossfade must be a number of seconds between 0 and 12", c.log)
+		return false
+	}
+	gconf.Crossfade = v
+	if v == 0 {
+		message.SendShortTimedNotice(s, m, "Got it, tracks will now follow each other without any gap", c.log)
+	} else {
+		message.SendShortTimedNotice(s, m, fmt.Sprintf("Got it, tracks will now crossfade over %d seconds", v), c.log)
+	}
+	return true
+}
+
+func (c *setup) handleHistory(s *discordgo.Session, m *discordgo.Message, gconf *models.Conf, value string) bool {
+	v, err := strconv.Atoi(value)
+	if err != nil || v < 1 || v > 500 {
+		message.SendShortTimedNotice(s, m, "The history size must be a number of tracks between 1 and 500", c.log)
+		return false
+	}
+	gconf.QueueHistory = v
+	message.SendShortTimedNotice(s, m, fmt.Sprintf("Got it, I will now remember the last %d played tracks", v), c.log)
+	return true
+}
+
+func (c *setup) handleTimezone(s *discordgo.Session, m *discordgo.Message, gconf *models.Conf, value string) bool {
+	if _, err := time.LoadLocation(value); err != nil || value == "" || value == "Local" {
+		message.SendShortTimedNotice(s, m, "Unknown timezone, use a name such as `Europe/Paris` or `America/New_York`", c.log)
+		return false
+	}
+	gconf.Timezone = value
+	message.SendShortTimedNotice(s, m, fmt.Sprintf("Got it, scheduled sessions will now use the %s timezone", value), c.log)
+	return true
+}
 
-	if gconf, err = c.Storage.GetGuildConf(m.GuildID); err != nil {
-		c.log.Err(err).Msg("unable to fetch guild conf")
+func (c *setup) handleVoteSkip(s *discordgo.Session, m *discordgo.Message, gconf *models.Conf, value string) bool {
+	if value == "off" {
+		gconf.VoteSkip = 0
+		message.SendShortTimedNotice(s, m, "Okay, only DJs and admins can skip tracks now", c.log)
+		return true
+	}
+	v, err := strconv.Atoi(strings.TrimSuffix(value, "%"))
+	if err != nil || v < 1 || v > 100 {
+		message.SendShortTimedNotice(s, m, "The share of listeners must be a percentage between 1 and 100, or `off`", c.log)
+		return false
+	}
+	gconf.VoteSkip = v
+	message.SendShortTimedNotice(s, m, fmt.Sprintf("Got it, a track will now be skipped once %d%% of the listeners voted", v), c.log)
+	return true
+}
+
+// limitString returns the number of tracks of a limit, or unlimited when it's
+// zero
+func limitString(v int) string {
+	if v == 0 {
+		return "unlimited"
+	}
+	return fmt.Sprintf("%d tracks", v)
+}
+
+func (c *setup) handleLimits(s *discordgo.Session, m *discordgo.Message, gconf *models.Conf, value string) bool {
+	l := &gconf.Limits
+	fields := strings.Fields(value)
+	if len(fields) == 1 && fields[0] == "show" {
+		dur := "unlimited"
+		if l.Duration > 0 {
+			dur = l.Duration.String()
+		}
+		msg := fmt.Sprintf(
+			"**Queue:** %s\n**Per requester:** %s\n**Track duration:** %s\n**Playlist import:** %s",
+			limitString(l.Queue), limitString(l.User), dur, limitString(l.Playlist),
+		)
+		if err := message.SendReply(s, m, "Queue limits", msg, ""); err != nil {
+			c.log.Err(err).Msg("unable to send reply")
+		}
+		return false
+	}
+	if len(fields) != 2 {
+		message.SendShortTimedNotice(s, m, "Use `setup limits <queue|user|duration|playlist> <value>`, `off` to remove a limit, or `setup limits show`", c.log)
+		return false
+	}
+
+	name, v := fields[0], fields[1]
+	if name == "duration" {
+		var d time.Duration
+		if v != "off" {
+			var rel bool
+			var err error
+			if d, rel, err = parsePosition(v); err != nil || rel {
+				message.SendShortTimedNotice(s, m, "The maximum duration must look like `10:00`, `600` or `10m`, or `off`", c.log)
+				return false
+			}
+		}
+		l.Duration = d
+		if d == 0 {
+			message.SendShortTimedNotice(s, m, "Got it, tracks can now be as long as they want", c.log)
+		} else {
+			message.SendShortTimedNotice(s, m, fmt.Sprintf("Got it, tracks longer than %s won't be added anymore", d), c.log)
+		}
+		return true
+	}
+
+	var n int
+	if v != "off" {
+		var err error
+		if n, err = strconv.Atoi(v); err != nil || n < 0 {
+			message.SendShortTimedNotice(s, m, "The limit must be a positive number of tracks, or `off`", c.log)
+			return false
+		}
+	}
+	switch name {
+	case "queue":
+		l.Queue = n
+	case "user":
+		l.User = n
+	case "playlist":
+		l.Playlist = n
+	default:
+		message.SendShortTimedNotice(s, m, "Unknown limit, use either `queue`, `user`, `duration` or `playlist`", c.log)
+		return false
+	}
+	if n == 0 {
+		message.SendShortTimedNotice(s, m, fmt.Sprintf("Got it, the %s limit was removed", name), c.log)
+	} else {
+		message.SendShortTimedNotice(s, m, fmt.Sprintf("Got it, the %s limit is now %d tracks", name, n), c.log)
+	}
+	return true
+}
+
+func (c *setup) handleLoudness(s *discordgo.Session, m *discordgo.Message, gconf *models.Conf, value string) bool {
+	if value == "off" {
+		gconf.Loudness = 0
+		message.SendShortTimedNotice(s, m, "Okay, I won't normalize the loudness of tracks anymore", c.log)
+		return true
+	}
+	v, err := strconv.Atoi(strings.TrimSuffix(strings.ToUpper(value), "LUFS"))
+	if err != nil || v < -31 || v > -5 {
+		message.SendShortTimedNotice(s, m, "The loudness target must be between -31 and -5 LUFS, or `off`", c.log)
+		return false
+	}
+	gconf.Loudness = v
+	message.SendShortTimedNotice(s, m, fmt.Sprintf("Got it, tracks will now be normalized to %d LUFS", v), c.log)
+	return true
+}
+
+func (c *setup) handleFollow(s *discordgo.Session, m *discordgo.Message, gconf *models.Conf, value string) bool {
+	switch value {
+	case "on":
+		gconf.FollowCaller = true
+		message.SendShortTimedNotice(s, m, "Alright, I'll join the vocal channel of whoever starts playing", c.log)
+	case "off":
+		gconf.FollowCaller = false
+		message.SendShortTimedNotice(s, m, "Alright, I'll stick to the configured vocal channel", c.log)
+	default:
+		message.SendShortTimedNotice(s, m, "This setting must be either `on` or `off`", c.log)
+		return false
+	}
+	return true
+}
+
+func (c *setup) handleFairQueue(s *discordgo.Session, m *discordgo.Message, gconf *models.Conf, value string) bool {
+	switch value {
+	case "on":
+		gconf.FairQueue = true
+		message.SendShortTimedNotice(s, m, "Alright, requesters will now take turns in the queue", c.log)
+	case "off":
+		gconf.FairQueue = false
+		message.SendShortTimedNotice(s, m, "Alright, tracks will now be played in the order they were added", c.log)
+	default:
+		message.SendShortTimedNotice(s, m, "This setting must be either `on` or `off`", c.log)
+		return false
+	}
+	return true
+}
+
+func (c *setup) handleResume(s *discordgo.Session, m *discordgo.Message, gconf *models.Conf, value string) bool {
+	switch value {
+	case "on":
+		gconf.Resume = true
+		message.SendShortTimedNotice(s, m, "Alright, I'll resume playing where I left off after a restart", c.log)
+	case "off":
+		gconf.Resume = false
+		message.SendShortTimedNotice(s, m, "Alright, I'll only restore the queue after a restart", c.log)
+	default:
+		message.SendShortTimedNotice(s, m, "This setting must be either `on` or `off`", c.log)
+		return false
+	}
+	return true
+}
+
+func (c *setup) handleQuality(s *discordgo.Session, m *discordgo.Message, gconf *models.Conf, value string) bool {
+	if value == "auto" {
+		gconf.Bitrate = 0
+		message.SendShortTimedNotice(s, m, "Alright, I'll match the bitrate of the vocal channel", c.log)
+		return true
+	}
+	v, err := strconv.Atoi(strings.TrimSuffix(strings.ToLower(value), "k"))
+	if err != nil || v < 8 || v > 384 {
+		message.SendShortTimedNotice(s, m, "The bitrate must be between 8 and 384 kb/s, or `auto`", c.log)
+		return false
+	}
+	gconf.Bitrate = v
+	message.SendShortTimedNotice(s, m, fmt.Sprintf("Got it, tracks will now be encoded at %d kb/s", v), c.log)
+	return true
+}
+
+func (c *setup) Handler(s *discordgo.Session, m *discordgo.Message, args []string) {
+	pl := c.Players.GetPlayer(m.GuildID)
+	if pl == nil {
+		c.log.Error().Msg("no player associated to guild ID")
 		return
 	}
 
@@ -59,25 +266,44 @@ func (c *setup) Handler(s *discordgo.Session, m *discordgo.Message, args []strin
 
 	v := strings.Join(args[1:], " ")
 	param, value := args[0], strings.Trim(v, `"`)
-	switch param {
-	case "voice":
-		c.handleVoiceChannel(s, m, gconf, value)
-		if pl := c.Players.GetPlayer(m.GuildID); pl != nil {
-			pl.UpdateConf(gconf)
+	err := pl.EditConf(func(gconf *models.Conf) bool {
+		switch param {
+		case "voice":
+			return c.handleVoiceChannel(s, m, gconf, value)
+		case "text":
+			return c.handleTextChannel(s, m, gconf, value)
+		case "crossfade":
+			return c.handleCrossfade(s, m, gconf, value)
+		case "follow":
+			return c.handleFollow(s, m, gconf, value)
+		case "resume":
+			return c.handleResume(s, m, gconf, value)
+		case "quality":
+			return c.handleQuality(s, m, gconf, value)
+		case "history":
+			return c.handleHistory(s, m, gconf, value)
+		case "timezone":
+			return c.handleTimezone(s, m, gconf, value)
+		case "loudness":
+			return c.handleLoudness(s, m, gconf, value)
+		case "limits":
+			return c.handleLimits(s, m, gconf, value)
+		case "fairqueue":
+			return c.handleFairQueue(s, m, gconf, value)
+		case "voteskip":
+			return c.handleVoteSkip(s, m, gconf, value)
+		default:
+			message.SendShortTimedNotice(s, m, "Unknwon parameter", c.log)
+			return false
 		}
-	case "text":
-		c.handleTextChannel(s, m, gconf, value)
-	default:
-		message.SendShortTimedNotice(s, m, "Unknwon parameter", c.log)
-		return
-	}
-
-	if err := c.Storage.SaveGuildConf(gconf); err != nil {
+	})
+	if err != nil {
 		c.log.Err(err).Msg("unable to save guild state")
+		message.SendShortTimedNotice(s, m, "I couldn't save this setting", c.log)
 	}
 }
 
-func NewSetupCommand(p *player.Players, log zerolog.Logger, storage *storage.BoltStorage) Command {
+func NewSetupCommand(p *player.Players, log zerolog.Logger) Command {
 	cmd := "setup"
 	return &setup{
 		BaseCommand: BaseCommand{
@@ -90,6 +316,16 @@ func NewSetupCommand(p *player.Players, log zerolog.Logger, storage *storage.Bol
 			SubCommands: []SubCommand{
 				{Long: "voice", Arg: "voice channel name", Description: "Setup the voice channel"},
 				{Long: "text", Arg: "text channel name", Description: "Setup the text channel"},
+				{Long: "crossfade", Arg: "seconds", Description: "Setup the crossfade between tracks, 0 for gapless playback"},
+				{Long: "follow", Arg: "on/off", Description: "Join the vocal channel of the caller instead of the configured one"},
+				{Long: "resume", Arg: "on/off", Description: "Resume playback where it left off after a restart"},
+				{Long: "quality", Arg: "kb/s", Description: "Override the encoding bitrate, `auto` to match the vocal channel"},
+				{Long: "history", Arg: "tracks", Description: "Setup how many played tracks are kept in history"},
+				{Long: "timezone", Arg: "name", Description: "Setup the timezone of scheduled sessions"},
+				{Long: "loudness", Arg: "LUFS", Description: "Normalize the loudness of tracks to the given target, `off` to disable"},
+				{Long: "limits", Arg: "limit value", Description: "Limit the `queue` size, tracks per `user`, track `duration` or `playlist` imports, `show` to display them"},
+				{Long: "fairqueue", Arg: "on/off", Description: "Interleave the tracks of the requesters so that they take turns"},
+				{Long: "voteskip", Arg: "percent", Description: "Let everyone vote to skip, with the share of listeners needed, `off` to disable"},
 			},
 			Long: cmd,
 			Help: Help{
@@ -100,11 +336,22 @@ func NewSetupCommand(p *player.Players, log zerolog.Logger, storage *storage.Bol
 					{Command: `setup voice "My Vocal Channel"`, Explanation: "Setup the vocal channel of the bot"},
 					{Command: `setup text fox-radio`, Explanation: "Setup the text channel of the bot"},
 					{Command: `setup djrole DJ`, Explanation: "Setup the privileged DJ role"},
+					{Command: `setup crossfade 5`, Explanation: "Crossfade tracks over 5 seconds"},
+					{Command: `setup follow on`, Explanation: "Join the vocal channel of whoever starts playing"},
+					{Command: `setup resume on`, Explanation: "Resume playback after a restart"},
+					{Command: `setup quality 128`, Explanation: "Encode tracks at 128 kb/s"},
+					{Command: `setup loudness -14`, Explanation: "Normalize tracks to -14 LUFS"},
+					{Command: `setup history 100`, Explanation: "Keep the last 100 played tracks in history"},
+					{Command: `setup timezone Europe/Paris`, Explanation: "Schedule sessions using Paris time"},
+					{Command: `setup limits user 20`, Explanation: "Allow at most 20 tracks per requester in queue"},
+					{Command: `setup limits duration 15m`, Explanation: "Refuse tracks longer than 15 minutes"},
+					{Command: `setup limits playlist off`, Explanation: "Import playlists entirely"},
+					{Command: `setup fairqueue on`, Explanation: "Let requesters take turns in the queue"},
+					{Command: `setup voteskip 50`, Explanation: "Skip tracks once half of the listeners voted"},
 				},
 			},
 			Players: p,
 			log:     log.With().Str("command", cmd).Logger(),
 		},
-		Storage: storage,
 	}
 }
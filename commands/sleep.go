@@ -0,0 +1,117 @@
+package commands
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/rs/zerolog"
+
+	"github.com/depado/fox/acl"
+	"github.com/depado/fox/message"
+	"github.com/depado/fox/player"
+)
+
+type sleep struct {
+	BaseCommand
+}
+
+func (c *sleep) Handler(s *discordgo.Session, m *discordgo.Message, args []string) {
+	p := c.Players.GetPlayer(m.GuildID)
+	if p == nil {
+		c.log.Error().Msg("no player associated to guild ID")
+		return
+	}
+
+	if len(args) < 1 {
+		message.SendShortTimedNotice(s, m, fmt.Sprintf("💤 Sleep timer: %s", p.SleepStatus()), c.log)
+		return
+	}
+
+	var msg string
+	switch args[0] {
+	case "cancel", "off", "c":
+		if p.SleepStatus().Mode == player.SleepOff {
+			message.SendShortTimedNotice(s, m, "There is no sleep timer to cancel", c.log)
+			return
+		}
+		p.CancelSleep()
+		msg = fmt.Sprintf("💤 <@%s> cancelled the sleep timer", m.Author.ID)
+	case "end", "e":
+		if !p.Playing() {
+			message.SendShortTimedNotice(s, m, "No track is currently playing", c.log)
+			return
+		}
+		p.SleepAfter(1)
+		msg = fmt.Sprintf("💤 <@%s> set the sleep timer, I'll stop after this track", m.Author.ID)
+	case "queue", "q":
+		if len(args) < 2 {
+			message.SendShortTimedNotice(s, m, "How many tracks should I play before stopping?", c.log)
+			return
+		}
+		n, err := strconv.Atoi(args[1])
+		if err != nil || n < 1 {
+			message.SendShortTimedNotice(s, m, "The number of tracks is invalid", c.log)
+			return
+		}
+		msg = fmt.Sprintf("💤 <@%s> set the sleep timer, I'll stop after %d more tracks", m.Author.ID, n)
+		if p.Playing() {
+			// The current track is played until its end too
+			n++
+		}
+		p.SleepAfter(n)
+	default:
+		d, err := time.ParseDuration(args[0])
+		if n, nerr := strconv.Atoi(args[0]); nerr == nil {
+			d, err = time.Duration(n)*time.Minute, nil
+		}
+		if err != nil || d < time.Minute || d > 24*time.Hour {
+			message.SendShortTimedNotice(s, m, "Invalid duration, use something like `45m` or `1h30m`", c.log)
+			return
+		}
+		p.SleepIn(d)
+		msg = fmt.Sprintf("💤 <@%s> set the sleep timer, %s", m.Author.ID, p.SleepStatus())
+	}
+
+	if err := message.SendReply(s, m, "", msg, ""); err != nil {
+		c.log.Err(err).Msg("unable to send reply")
+	}
+}
+
+func NewSleepCommand(p *player.Players, log zerolog.Logger) Command {
+	cmd := "sleep"
+	return &sleep{
+		BaseCommand{
+			ChannelRestriction: acl.Music,
+			RoleRestriction:    acl.Privileged,
+			Options: Options{
+				ArgsRequired:      false,
+				DeleteUserMessage: true,
+			},
+			Long: cmd,
+			SubCommands: []SubCommand{
+				{Long: "end", Aliases: []string{"e"}, Description: "Stop after the current track"},
+				{Long: "queue", Aliases: []string{"q"}, Arg: "tracks", Description: "Stop after the given number of tracks"},
+				{Long: "cancel", Aliases: []string{"off", "c"}, Description: "Cancel the sleep timer"},
+			},
+			Help: Help{
+				Usage:     cmd,
+				ShortDesc: "Stop playback after a while",
+				Description: "This command sets a sleep timer. Once it's over, " +
+					"the music fades out and playback stops. The timer can be " +
+					"a duration, the end of the current track or a number of " +
+					"tracks. A duration without unit is a number of minutes.",
+				Examples: []Example{
+					{Command: "sleep 45m", Explanation: "Stop in 45 minutes"},
+					{Command: "sleep end", Explanation: "Stop after the current track"},
+					{Command: "sleep queue 3", Explanation: "Stop after 3 more tracks"},
+					{Command: "sleep cancel", Explanation: "Cancel the sleep timer"},
+					{Command: "sleep", Explanation: "Display the sleep timer"},
+				},
+			},
+			Players: p,
+			log:     log.With().Str("command", cmd).Logger(),
+		},
+	}
+}
@@ -24,24 +24,27 @@ func (c *stats) Handler(s *discordgo.Session, m *discordgo.Message, args []strin
 		return
 	}
 
-	if p.Stats == nil {
+	st := p.Stats()
+	if st == nil {
 		message.SendShortTimedNotice(s, m, "There is no encoding session", c.log)
 		return
 	}
 
-	p.Stats.RLock()
+	st.RLock()
 	e := &discordgo.MessageEmbed{
 		Title: "📈 Stream & encoding stats",
 		Color: 0xff5500,
 		Fields: []*discordgo.MessageEmbedField{
-			{Name: "Playback", Value: p.Stats.PlaybackPosition.String(), Inline: true},
-			{Name: "Encoded", Value: p.Stats.Duration.String(), Inline: true},
-			{Name: "Size", Value: fmt.Sprintf("%5d kB", p.Stats.Size), Inline: true},
-			{Name: "Bitrate", Value: fmt.Sprintf("%6.2f kB/s", p.Stats.Bitrate), Inline: true},
-			{Name: "Speed", Value: fmt.Sprintf("%5.1fx", p.Stats.Speed), Inline: true},
+			{Name: "Playback", Value: st.PlaybackPosition.String(), Inline: true},
+			{Name: "Encoded", Value: st.Duration.String(), Inline: true},
+			{Name: "Size", Value: fmt.Sprintf("%5d kB", st.Size), Inline: true},
+			{Name: "Bitrate", Value: fmt.Sprintf("%6.2f kB/s", st.Bitrate), Inline: true},
+			{Name: "Speed", Value: fmt.Sprintf("%5.1fx", st.Speed), Inline: true},
+			{Name: "Filters", Value: p.FiltersString(), Inline: true},
+			{Name: "Target bitrate", Value: fmt.Sprintf("%d kb/s", p.Bitrate()), Inline: true},
 		},
 	}
-	p.Stats.RUnlock()
+	st.RUnlock()
 
 	msg := &discordgo.MessageSend{
 		Embed: e,
@@ -50,7 +53,7 @@ func (c *stats) Handler(s *discordgo.Session, m *discordgo.Message, args []strin
 	if len(args) > 0 {
 		switch args[0] {
 		case "chart", "c", "graph", "g":
-			g := p.Stats.GenerateChart()
+			g := st.GenerateChart()
 			if g != nil {
 				buffer := bytes.NewBuffer([]byte{})
 				if err := g.Render(chart.PNG, buffer); err != nil {
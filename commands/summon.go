@@ -0,0 +1,87 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/rs/zerolog"
+
+	"github.com/depado/fox/acl"
+	"github.com/depado/fox/message"
+	"github.com/depado/fox/player"
+)
+
+// callerVoiceChannel returns the ID of the voice channel the author of the
+// message is currently in, or an empty string if they aren't in any.
+func callerVoiceChannel(s *discordgo.Session, m *discordgo.Message) string {
+	vs, err := s.State.VoiceState(m.GuildID, m.Author.ID)
+	if err != nil {
+		return ""
+	}
+	return vs.ChannelID
+}
+
+type summon struct {
+	BaseCommand
+}
+
+func (c *summon) Handler(s *discordgo.Session, m *discordgo.Message, args []string) {
+	p := c.Players.GetPlayer(m.GuildID)
+	if p == nil {
+		c.log.Error().Msg("no player associated to guild ID")
+		return
+	}
+
+	if !p.Playing() {
+		message.SendShortTimedNotice(s, m, "No track is currently playing", c.log)
+		return
+	}
+
+	ch := callerVoiceChannel(s, m)
+	if ch == "" {
+		message.SendShortTimedNotice(s, m, "You need to be in a voice channel so I can join you", c.log)
+		return
+	}
+	if ch == p.VoiceChannel() {
+		message.SendShortTimedNotice(s, m, "I'm already in your voice channel", c.log)
+		return
+	}
+
+	if err := p.SetVoiceChannel(ch); err != nil {
+		c.log.Err(err).Msg("unable to move to voice channel")
+		message.SendShortTimedNotice(s, m, "I couldn't join your voice channel", c.log)
+		return
+	}
+	msg := fmt.Sprintf("🚚 <@%s> summoned me to <#%s>", m.Author.ID, ch)
+	if err := message.SendReply(s, m, "", msg, ""); err != nil {
+		c.log.Err(err).Msg("unable to send reply")
+	}
+}
+
+func NewSummonCommand(p *player.Players, log zerolog.Logger) Command {
+	cmd := "summon"
+	return &summon{
+		BaseCommand{
+			ChannelRestriction: acl.Music,
+			RoleRestriction:    acl.Privileged,
+			Options: Options{
+				ArgsRequired:      false,
+				DeleteUserMessage: true,
+			},
+			Long: cmd,
+			Help: Help{
+				Usage:     cmd,
+				ShortDesc: "Move the player to your voice channel",
+				Description: "This command will move the player to the voice " +
+					"channel you are currently in, without interrupting the " +
+					"current track or losing the queue. This command has no " +
+					"effect if the player isn't running.",
+				Examples: []Example{
+					{Command: "summon", Explanation: "Move the player to your voice channel"},
+				},
+			},
+			Players: p,
+			log:     log.With().Str("command", cmd).Logger(),
+		},
+	}
+}
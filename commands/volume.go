@@ -75,6 +75,9 @@ func (c *volume) Handler(s *discordgo.Session, m *discordgo.Message, args []stri
 		emoji = "🔈"
 	}
 	body := fmt.Sprintf("%s Volume set to %d%% by <@%s>", emoji, v, m.Author.ID)
+	if p.Playing() {
+		body += "\nThe change was applied to the current track"
+	}
 	if err := message.SendReply(s, m, "", body, ""); err != nil {
 		c.log.Err(err).Msg("unable to send reply")
 	}
@@ -95,10 +98,10 @@ func NewVolumeCommand(p *player.Players, log zerolog.Logger) Command {
 			Help: Help{
 				Usage:     cmd,
 				ShortDesc: "Set or see the volume of the player",
-				Description: "This command will set the volume for the " +
-					"following tracks or display the current volume if no " +
-					"argument is provided. The volume change will be applied " +
-					"to the next tracks and not to the currently playing one.",
+				Description: "This command will set the volume of the player " +
+					"or display the current volume if no argument is provided. " +
+					"The volume change is applied immediately to the currently " +
+					"playing track.",
 				Examples: []Example{
 					{Command: "volume", Explanation: "Display the current volume"},
 					{Command: "volume reset", Explanation: "Resets the volume to 100%"},
@@ -1,7 +1,6 @@
 package main
 
 import (
-	"github.com/Depado/soundcloud"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
 	"go.uber.org/fx"
@@ -11,6 +10,7 @@ import (
 	"github.com/depado/fox/cmd"
 	"github.com/depado/fox/commands"
 	"github.com/depado/fox/player"
+	"github.com/depado/fox/scheduler"
 	sp "github.com/depado/fox/soundcloud"
 	"github.com/depado/fox/storage"
 )
@@ -24,11 +24,11 @@ var rootCmd = &cobra.Command{
 			fx.NopLogger,
 			fx.Provide(
 				cmd.NewConf, cmd.NewLogger, acl.NewACL, player.NewPlayers, storage.NewBoltStorage,
-				soundcloud.NewAutoIDClient, sp.NewSoundCloudProvider,
+				sp.NewClientID, sp.NewClient, sp.NewSoundCloudProvider,
 				commands.InitializeAllCommands,
-				bot.NewBot,
+				bot.NewBot, scheduler.NewScheduler,
 			),
-			fx.Invoke(bot.Run),
+			fx.Invoke(bot.Run, scheduler.Run),
 		).Run()
 	},
 }
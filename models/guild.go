@@ -13,11 +13,35 @@ var (
 
 // Conf represents the guild conf at a given point.
 type Conf struct {
-	ID             string `json:"-"`
-	VoiceChannel   string `json:"voice"`
-	TextChannel    string `json:"text"`
-	QueueHistory   int    `json:"history"`
-	PrivilegedRole string `json:"privileged_role"`
+	ID             string   `json:"-"`
+	VoiceChannel   string   `json:"voice"`
+	TextChannel    string   `json:"text"`
+	QueueHistory   int      `json:"history"`
+	PrivilegedRole string   `json:"privileged_role"`
+	Crossfade      int      `json:"crossfade"`
+	Filters        []string `json:"filters"`
+	Loudness       int      `json:"loudness"`
+	FollowCaller   bool     `json:"follow_caller"`
+	Resume         bool     `json:"resume"`
+	Bitrate        int      `json:"bitrate"`
+	Autoplay       bool     `json:"autoplay"`
+	Timezone       string   `json:"timezone"`
+	Panel          bool     `json:"panel"`
+	VoteSkip       int      `json:"vote_skip"`
+	FairQueue      bool     `json:"fair_queue"`
+	Limits         Limits   `json:"limits"`
+}
+
+// Limits bound what users can add to the queue, zero meaning unlimited.
+type Limits struct {
+	// Queue is the maximum number of tracks in queue
+	Queue int `json:"queue"`
+	// User is the maximum number of tracks in queue per requester
+	User int `json:"user"`
+	// Duration is the maximum duration of a single track
+	Duration time.Duration `json:"duration"`
+	// Playlist is the maximum number of tracks imported from a playlist
+	Playlist int `json:"playlist"`
 }
 
 type Info struct {
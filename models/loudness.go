@@ -0,0 +1,10 @@
+package models
+
+// Loudness holds the EBU R128 loudness measurements of a track, as reported by
+// the first pass of ffmpeg's loudnorm filter.
+type Loudness struct {
+	Integrated float64 `json:"integrated"`
+	TruePeak   float64 `json:"true_peak"`
+	Range      float64 `json:"range"`
+	Threshold  float64 `json:"threshold"`
+}
@@ -0,0 +1,8 @@
+package models
+
+// Panel is the live now-playing message of a guild, kept so that the same
+// message is edited across restarts.
+type Panel struct {
+	Channel string `json:"channel"`
+	Message string `json:"message"`
+}
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// Schedule is a recurring playback session of a guild.
+type Schedule struct {
+	ID   int    `json:"id"`
+	Cron string `json:"cron"`
+	URL  string `json:"url"`
+	// Shuffle the playlist before queueing it
+	Shuffle bool `json:"shuffle"`
+	// Volume in percent, zero keeps the current volume
+	Volume int `json:"volume"`
+	// Duration after which playback stops, zero plays the whole playlist
+	Duration  time.Duration `json:"duration"`
+	CreatedBy string        `json:"created_by"`
+}
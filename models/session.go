@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+
+	"github.com/Depado/soundcloud"
+)
+
+// SavedTrack is the serializable form of a track in queue.
+type SavedTrack struct {
+	Track     soundcloud.Track `json:"track"`
+	User      string           `json:"user"`
+	UserID    string           `json:"user_id"`
+	AvatarURL string           `json:"avatar_url"`
+}
+
+// Session is the state of a guild's player, saved so that it can be restored
+// across restarts.
+type Session struct {
+	Tracks   []SavedTrack  `json:"tracks"`
+	Position time.Duration `json:"position"`
+	Volume   int           `json:"volume"`
+	Loop     int           `json:"loop"`
+	Playing  bool          `json:"playing"`
+	SavedAt  time.Time     `json:"saved_at"`
+}
@@ -0,0 +1,78 @@
+package player
+
+import (
+	"fmt"
+
+	"github.com/depado/fox/models"
+	"github.com/depado/fox/tracks"
+)
+
+const (
+	// autoplaySeeds is the number of last played tracks used to find related
+	// tracks
+	autoplaySeeds = 3
+	// autoplayBatch is the number of tracks added to the queue at once
+	autoplayBatch = 5
+)
+
+// SetAutoplay will enable or disable autoplay and save the guild conf.
+func (p *Player) SetAutoplay(on bool) error {
+	if err := p.editConf(func(gc *models.Conf) { gc.Autoplay = on }); err != nil {
+		return fmt.Errorf("save guild conf: %w", err)
+	}
+	return nil
+}
+
+// autoplay will add tracks related to the last played ones to the queue if
+// autoplay is enabled, leaving out the tracks found in history. Finding them
+// takes several requests to SoundCloud, so they are fetched in another
+// goroutine while the commands keep being handled. It returns whether tracks
+// were added, and gives up if playback is stopped meanwhile.
+func (p *Player) autoplay() bool {
+	if !p.GuildConf().Autoplay || p.sp == nil {
+		return false
+	}
+
+	es := p.History.Entries()
+	if len(es) == 0 {
+		return false
+	}
+	played := make(map[string]bool, len(es))
+	seeds := tracks.Tracks{}
+	for i, e := range es {
+		played[e.Track.ID()] = true
+		if i < autoplaySeeds {
+			seeds = append(seeds, e.Track)
+		}
+	}
+
+	found := make(chan tracks.Tracks, 1)
+	go func() {
+		tr, err := p.sp.Related(seeds, autoplayBatch, func(id string) bool { return played[id] })
+		if err != nil {
+			p.log.Err(err).Msg("unable to find tracks to autoplay")
+		}
+		found <- tr
+	}()
+
+	for {
+		select {
+		case tr := <-found:
+			if len(tr) == 0 {
+				return false
+			}
+			p.log.Debug().Int("tracks", len(tr)).Msg("autoplay filled the queue")
+			p.Queue.Append(tr...)
+			return true
+		case c := <-p.commands:
+			if end, _ := p.handle(nil, c); end && p.Stopped() {
+				return false
+			}
+			// No track is playing, there is nothing to skip
+			p.skipped, p.rewind = false, false
+		case <-p.quit:
+			p.update(func(s *State) { s.Stopped = true })
+			return false
+		}
+	}
+}
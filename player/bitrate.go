@@ -0,0 +1,30 @@
+package player
+
+// defaultBitrate is the bitrate used when the voice channel's one is unknown,
+// it matches the default bitrate of Discord voice channels
+const defaultBitrate = 96
+
+// Bitrate returns the bitrate, in kb/s, tracks are encoded to. It's either the
+// guild's override or the bitrate of the voice channel the player joined.
+func (p *Player) Bitrate() int {
+	if b := p.GuildConf().Bitrate; b > 0 {
+		return b
+	}
+	if b := p.Snapshot().Bitrate; b > 0 {
+		return b
+	}
+	return defaultBitrate
+}
+
+// updateBitrate will fetch the bitrate of the given voice channel so that
+// tracks are encoded to match it.
+func (p *Player) updateBitrate(channelID string) {
+	ch, err := p.session.State.Channel(channelID)
+	if err != nil {
+		if ch, err = p.session.Channel(channelID); err != nil {
+			p.log.Err(err).Msg("unable to get voice channel bitrate")
+			return
+		}
+	}
+	p.update(func(s *State) { s.Bitrate = ch.Bitrate / 1000 })
+}
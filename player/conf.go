@@ -1,15 +1,71 @@
 package player
 
-import "github.com/depado/fox/models"
+import (
+	"fmt"
+	"slices"
 
+	"github.com/depado/fox/models"
+)
+
+// UpdateConf will replace the conf of the player and apply the changes. The
+// given conf must not be modified afterwards.
 func (p *Player) UpdateConf(gc *models.Conf) {
+	p.send(confCmd{conf: gc})
+}
+
+// applyConf will replace the conf of the player and apply the changes to the
+// given playback if any.
+func (p *Player) applyConf(pb *playback, gc *models.Conf) {
 	log := p.log.With().Str("action", "conf_update").Logger()
-	if p.Conf.VoiceChannel != gc.VoiceChannel {
-		log.Debug().Str("old", p.Conf.VoiceChannel).Str("new", gc.VoiceChannel).Msg("voice channel changed")
-		if p.Playing() {
-			p.Stop()
-			p.Play()
+	old := p.GuildConf()
+	p.update(func(s *State) { s.conf = gc })
+
+	if old.VoiceChannel != gc.VoiceChannel {
+		log.Debug().Str("old", old.VoiceChannel).Str("new", gc.VoiceChannel).Msg("voice channel changed")
+		if p.channel == "" {
+			if err := p.switchChannel(pb, gc.VoiceChannel); err != nil {
+				log.Err(err).Msg("unable to move to the new voice channel")
+			}
 		}
 	}
-	p.Conf = gc
+	p.applyPanel(old, gc)
+	if gc.FairQueue && !old.FairQueue {
+		p.Queue.Rebalance()
+	}
+	if old.QueueHistory != gc.QueueHistory {
+		p.History.SetSize(gc.QueueHistory)
+	}
+	if old.Loudness != gc.Loudness || old.Bitrate != gc.Bitrate || !slices.Equal(old.Filters, gc.Filters) {
+		p.reloadStream(pb)
+	}
+}
+
+// EditConf will modify the stored guild conf, save it and apply it, unless f
+// returns false. The stored conf is used rather than the one of the player so
+// that changes saved by other means are never overwritten, and edits are made
+// one at a time. The player only gets the conf once it's saved.
+func (p *Player) EditConf(f func(gc *models.Conf) bool) error {
+	p.editing.Lock()
+	defer p.editing.Unlock()
+
+	gc, err := p.Storage.GetGuildConf(p.Guild)
+	if err != nil {
+		return fmt.Errorf("get guild conf: %w", err)
+	}
+	if !f(gc) {
+		return nil
+	}
+	if err := p.Storage.SaveGuildConf(gc); err != nil {
+		return err
+	}
+	p.UpdateConf(gc)
+	return nil
+}
+
+// editConf will modify the stored guild conf, save it and apply it.
+func (p *Player) editConf(f func(gc *models.Conf)) error {
+	return p.EditConf(func(gc *models.Conf) bool {
+		f(gc)
+		return true
+	})
 }
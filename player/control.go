@@ -0,0 +1,210 @@
+package player
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/depado/fox/models"
+	"github.com/depado/fox/tracks"
+)
+
+// commandBuffer is the number of commands that can be sent to the player
+// without waiting for it to handle them
+const commandBuffer = 16
+
+// command is a request handled by the control goroutine of the player. The
+// control goroutine is the only one allowed to touch the voice connection and
+// the encoding and streaming sessions, and to modify the state.
+type command interface{}
+
+type (
+	// playCmd starts playing the queue, at the given position in the first
+	// track
+	playCmd struct{ at time.Duration }
+	// stopCmd stops playback, keeping the current track in queue
+	stopCmd struct{}
+	// skipCmd ends the current track
+	skipCmd struct{}
+	// previousCmd plays the given track before the current one
+	previousCmd struct{ track tracks.Track }
+	pauseCmd    struct{}
+	resumeCmd   struct{}
+	// seekCmd restarts the current track at the given position
+	seekCmd struct {
+		pos  time.Duration
+		done chan<- error
+	}
+	// reloadCmd restarts the current track at the current position to apply
+	// new encoding options
+	reloadCmd struct{}
+	volumeCmd struct{ volume int }
+	loopCmd   struct{ mode LoopMode }
+	// voiceCmd moves the player to the given voice channel
+	voiceCmd struct {
+		channel string
+		done    chan<- error
+	}
+	confCmd struct{ conf *models.Conf }
+	// listenersCmd gives the number of listeners left in the voice channel
+	listenersCmd struct{ count int }
+	// leaveCmd is sent once the given leave timer expires
+	leaveCmd struct{ timer *leaveTimer }
+	// panelCmd edits the panel, posting it if create is true
+	panelCmd struct{ create bool }
+	// repinCmd posts the panel again at the bottom of the text channel
+	repinCmd struct{ done chan<- error }
+)
+
+// send will send a command to the control goroutine. It returns false if the
+// player was killed.
+func (p *Player) send(c command) bool {
+	// A killed player could otherwise still accept commands while there is
+	// room in the buffer
+	select {
+	case <-p.quit:
+		return false
+	default:
+	}
+	select {
+	case p.commands <- c:
+		return true
+	case <-p.quit:
+		return false
+	}
+}
+
+// run is the control goroutine of the player. It handles the commands sent to
+// the player while it's idle, and plays the queue when asked to.
+func (p *Player) run() {
+	defer close(p.done)
+	for {
+		select {
+		case c := <-p.commands:
+			p.handle(nil, c)
+		case <-p.quit:
+			p.discardPreload()
+			if err := p.Disconnect(); err != nil {
+				p.log.Err(err).Msg("unable to disconnect from voice channel")
+			}
+			return
+		}
+	}
+}
+
+// handle will handle a command. The given playback is the track being
+// streamed, nil if there is none. It returns true when the current track must
+// end, and an error if it failed to restart its stream.
+func (p *Player) handle(pb *playback, c command) (bool, error) {
+	switch c := c.(type) {
+	case playCmd:
+		if !p.running {
+			p.startAt = c.at
+			p.playQueue()
+		}
+	case stopCmd:
+		if p.running {
+			p.update(func(s *State) { s.Stopped = true })
+			p.dropCrossfade()
+			return true, nil
+		}
+	case skipCmd:
+		if p.running {
+			p.skipped = true
+			p.dropCrossfade()
+			return true, nil
+		}
+	case previousCmd:
+		if !p.running {
+			p.Queue.Insert(0, c.track)
+			p.playQueue()
+			return false, nil
+		}
+		// The current track is played again right after the previous one
+		p.Queue.Insert(1, c.track)
+		p.skipped = true
+		p.rewind = true
+		p.dropCrossfade()
+		return true, nil
+	case pauseCmd:
+		if pb != nil {
+			p.stream.SetPaused(true)
+			p.update(func(s *State) { s.Paused = true })
+			p.emit(Event{Type: Paused})
+		}
+	case resumeCmd:
+		if pb != nil {
+			p.stream.SetPaused(false)
+			p.update(func(s *State) { s.Paused, s.AutoPaused = false, false })
+			p.emit(Event{Type: Resumed})
+		}
+	case seekCmd:
+		if pb == nil {
+			c.done <- ErrNotPlaying
+			return false, nil
+		}
+		// The duration of some streams is unknown
+		if c.pos < 0 || (pb.duration > 0 && c.pos >= pb.duration) {
+			c.done <- ErrSeekOutOfRange
+			return false, nil
+		}
+		c.done <- nil
+		return false, p.restart(pb, c.pos, false)
+	case reloadCmd:
+		p.reloadStream(pb)
+	case volumeCmd:
+		p.update(func(s *State) { s.Volume = c.volume })
+		p.emit(Event{Type: VolumeChanged, Volume: c.volume})
+		p.reloadStream(pb)
+	case loopCmd:
+		p.update(func(s *State) { s.Loop = c.mode })
+	case voiceCmd:
+		c.done <- p.moveVoice(pb, c.channel)
+	case confCmd:
+		p.applyConf(pb, c.conf)
+	case listenersCmd:
+		p.updateListeners(pb, c.count)
+	case leaveCmd:
+		return p.leaveEmpty(c.timer), nil
+	case panelCmd:
+		if err := p.refreshPanel(c.create); err != nil {
+			p.log.Err(err).Msg("unable to refresh panel")
+		}
+	case repinCmd:
+		c.done <- p.repinPanel()
+	default:
+		p.log.Error().Str("command", fmt.Sprintf("%T", c)).Msg("unknown command")
+	}
+	return false, nil
+}
+
+// reloadStream will restart the stream of the given playback at the current
+// position, so that new encoding options are applied.
+func (p *Player) reloadStream(pb *playback) {
+	if pb == nil {
+		return
+	}
+	if err := p.restart(pb, p.position(), true); err != nil {
+		p.log.Err(err).Msg("unable to apply encoding changes")
+	}
+}
+
+// wait will wait for the given duration while handling commands. It returns
+// false if the wait was interrupted because the track must end.
+func (p *Player) wait(d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-t.C:
+			return true
+		case c := <-p.commands:
+			if end, _ := p.handle(nil, c); end {
+				return false
+			}
+		case <-p.quit:
+			p.update(func(s *State) { s.Stopped = true })
+			return false
+		}
+	}
+}
@@ -22,12 +22,15 @@ func fmtDuration(d time.Duration) string {
 
 func (p *Player) GeneratePlayerString(dur time.Duration) string {
 	player := []rune("------------------------------")
-	pb := p.stream.PlaybackPosition()
-	pos := int(pb*100/dur) * len(player) / 100
-	if pos >= len(player) {
-		pos = len(player) - 1
+	pb := p.Position()
+	// The duration of some streams is unknown, the bar stays empty
+	if dur > 0 {
+		pos := int(pb*100/dur) * len(player) / 100
+		if pos >= len(player) {
+			pos = len(player) - 1
+		}
+		player[pos] = '●'
 	}
-	player[pos] = '●'
 
 	return fmt.Sprintf("%s  %s  %s", fmtDuration(pb), string(player), fmtDuration(dur))
 }
@@ -49,6 +52,16 @@ func (p *Player) GenerateNowPlayingEmbed(short bool) *discordgo.MessageEmbed {
 		IconURL: a,
 		Text:    "Added by " + u,
 	}
+	if l := p.Loop(); l != LoopOff {
+		e.Footer.Text += " • " + l.String()
+	}
+	gc := p.GuildConf()
+	if len(gc.Filters) > 0 {
+		e.Footer.Text += " • 🎛️ " + p.FiltersString()
+	}
+	if gc.Autoplay {
+		e.Footer.Text += " • 📻 Autoplay"
+	}
 	if short {
 		e.Fields = nil
 		e.Description = p.GeneratePlayerString(tot)
@@ -59,13 +72,21 @@ func (p *Player) GenerateNowPlayingEmbed(short bool) *discordgo.MessageEmbed {
 			Value:  fmt.Sprintf("%d tracks left in queue - %s", p.Queue.Len(), p.Queue.DurationString()),
 			Inline: false,
 		})
+		if sl := p.SleepStatus(); sl.Mode != SleepOff {
+			e.Fields = append(e.Fields, &discordgo.MessageEmbedField{
+				Name:   "💤 Sleep timer",
+				Value:  sl.String(),
+				Inline: false,
+			})
+		}
 	}
 
 	return e
 }
 
 func (p *Player) SendNotice(title, body, footer string) {
-	if p.Conf.TextChannel == "" {
+	ch := p.GuildConf().TextChannel
+	if ch == "" {
 		return
 	}
 
@@ -78,7 +99,7 @@ func (p *Player) SendNotice(title, body, footer string) {
 		Color: 0xff5500,
 	}
 
-	_, err := p.session.ChannelMessageSendEmbed(p.Conf.TextChannel, e)
+	_, err := p.session.ChannelMessageSendEmbed(ch, e)
 	if err != nil {
 		p.log.Err(err).Msg("unable to send embed")
 	}
@@ -0,0 +1,89 @@
+package player
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/jonas747/dca"
+)
+
+// encoder is an encoding session. When it starts mid-track, its input is
+// decoded by a separate ffmpeg process which seeks in the stream before
+// reading it, since dca only seeks in the output and would decode the track
+// from the start.
+type encoder struct {
+	*dca.EncodeSession
+
+	input *exec.Cmd
+	pipe  *os.File
+}
+
+// newEncoder will start an encoding session for the stream found at url,
+// starting at the given position in the track.
+func newEncoder(url string, pos time.Duration, opts *dca.EncodeOptions) (*encoder, error) {
+	if pos <= 0 {
+		es, err := dca.EncodeFile(url, opts)
+		if err != nil {
+			return nil, err
+		}
+		return &encoder{EncodeSession: es}, nil
+	}
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		return nil, fmt.Errorf("create pipe: %w", err)
+	}
+	input := exec.Command(
+		"ffmpeg", "-hide_banner", "-loglevel", "error",
+		"-reconnect", "1", "-reconnect_streamed", "1", "-reconnect_delay_max", "2",
+		"-ss", fmt.Sprintf("%.3f", pos.Seconds()),
+		"-i", url, "-map", "0:a",
+		"-c:a", "pcm_s16le", "-f", "wav", "pipe:1",
+	)
+	input.Stdout = w
+	if err := input.Start(); err != nil {
+		r.Close()
+		w.Close()
+		return nil, fmt.Errorf("start decoding: %w", err)
+	}
+	w.Close()
+	// The process is reaped whether it ends by itself or is killed
+	go input.Wait() // nolint: errcheck
+
+	es, err := dca.EncodeMem(r, opts)
+	if err != nil {
+		input.Process.Kill() // nolint: errcheck
+		r.Close()
+		return nil, err
+	}
+	return &encoder{EncodeSession: es, input: input, pipe: r}, nil
+}
+
+// Cleanup will stop the encoding session and the process decoding its input
+// if any.
+func (e *encoder) Cleanup() {
+	if e.input != nil {
+		// The encoding process stops once its input ends
+		e.input.Process.Kill() // nolint: errcheck
+	}
+	e.EncodeSession.Cleanup()
+	if e.pipe != nil {
+		e.pipe.Close()
+	}
+}
+
+// skipFrames will read and drop the frames covering the given duration, and
+// return the duration actually skipped.
+func skipFrames(src dca.OpusReader, d time.Duration, frameMs int) time.Duration {
+	frame := time.Duration(frameMs) * time.Millisecond
+	var skipped time.Duration
+	for skipped+frame <= d {
+		if _, err := src.OpusFrame(); err != nil {
+			break
+		}
+		skipped += frame
+	}
+	return skipped
+}
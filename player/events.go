@@ -0,0 +1,146 @@
+package player
+
+import (
+	"sync"
+	"time"
+
+	"github.com/depado/fox/tracks"
+)
+
+// eventBuffer is the number of events a subscriber can lag behind before
+// events get dropped
+const eventBuffer = 32
+
+// EventType is the kind of event emitted by a player
+type EventType int
+
+const (
+	// TrackStarted is emitted when a track starts streaming
+	TrackStarted EventType = iota
+	// TrackEnded is emitted when a track ended, was skipped or stopped
+	TrackEnded
+	// TrackFailed is emitted when a track was skipped because it couldn't be
+	// played
+	TrackFailed
+	// Paused is emitted when playback is paused
+	Paused
+	// Resumed is emitted when playback is resumed
+	Resumed
+	// QueueChanged is emitted when tracks are added, removed or moved in queue
+	QueueChanged
+	// VolumeChanged is emitted when the volume is changed
+	VolumeChanged
+	// Disconnected is emitted when the player leaves the voice channel
+	Disconnected
+)
+
+// String returns the name of the event type
+func (t EventType) String() string {
+	switch t {
+	case TrackStarted:
+		return "track_started"
+	case TrackEnded:
+		return "track_ended"
+	case TrackFailed:
+		return "track_failed"
+	case Paused:
+		return "paused"
+	case Resumed:
+		return "resumed"
+	case QueueChanged:
+		return "queue_changed"
+	case VolumeChanged:
+		return "volume_changed"
+	case Disconnected:
+		return "disconnected"
+	default:
+		return "unknown"
+	}
+}
+
+// Event is something that happened in a guild's player
+type Event struct {
+	Type  EventType
+	Guild string
+	At    time.Time
+	// Track is set for track events
+	Track tracks.Track
+	// Skipped is true when a track ended because it was skipped or stopped
+	Skipped bool
+	// Err is the reason why a track failed
+	Err error
+	// Volume is the new volume when it changed
+	Volume int
+}
+
+// Events dispatches events to their subscribers. A subscriber that doesn't
+// keep up misses events instead of blocking the player.
+type Events struct {
+	sync.RWMutex
+
+	subs map[int]chan Event
+	next int
+}
+
+// NewEvents returns a new event dispatcher without any subscriber
+func NewEvents() *Events {
+	return &Events{subs: make(map[int]chan Event)}
+}
+
+// Subscribe returns a channel receiving the events, and a function to call to
+// unsubscribe, which closes the channel.
+func (e *Events) Subscribe() (<-chan Event, func()) {
+	e.Lock()
+	defer e.Unlock()
+
+	id := e.next
+	e.next++
+	ch := make(chan Event, eventBuffer)
+	e.subs[id] = ch
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			e.Lock()
+			defer e.Unlock()
+			delete(e.subs, id)
+			close(ch)
+		})
+	}
+}
+
+// publish will send the event to all the subscribers without blocking
+func (e *Events) publish(ev Event) {
+	e.RLock()
+	defer e.RUnlock()
+
+	for _, ch := range e.subs {
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+}
+
+// Subscribe returns a channel receiving the events of all the players, and a
+// function to call to unsubscribe.
+func (p *Players) Subscribe() (<-chan Event, func()) {
+	return p.events.Subscribe()
+}
+
+// Subscribe returns a channel receiving the events of this player, and a
+// function to call to unsubscribe.
+func (p *Player) Subscribe() (<-chan Event, func()) {
+	return p.events.Subscribe()
+}
+
+// emit will publish the event to the subscribers of this player and of all
+// the players
+func (p *Player) emit(ev Event) {
+	ev.Guild = p.Guild
+	ev.At = time.Now()
+	p.events.publish(ev)
+	if p.hub != nil {
+		p.hub.publish(ev)
+	}
+}
@@ -0,0 +1,125 @@
+package player
+
+import (
+	"github.com/depado/fox/tracks"
+)
+
+// In fair mode, the upcoming tracks are split in rounds in which every
+// requester gets at most one track, so that a large playlist doesn't bury the
+// requests of everyone else. The tracks of a requester keep their order.
+
+// fair is used by the queue which already holds its own lock
+func (s *State) fair() bool {
+	s.RLock()
+	defer s.RUnlock()
+	return s.conf != nil && s.conf.FairQueue
+}
+
+// requester returns the ID of the user who added the track, names can be
+// shared and changed. The tracks added by the bot only have a name.
+func requester(t tracks.Track) string {
+	if id := t.GetUserID(); id != "" {
+		return id
+	}
+	u, _ := t.GetUser()
+	return u
+}
+
+// mention returns how the user who added the track is displayed, mentioning
+// them when their ID is known.
+func mention(t tracks.Track) string {
+	if id := t.GetUserID(); id != "" {
+		return "<@" + id + ">"
+	}
+	u, _ := t.GetUser()
+	return u
+}
+
+// fairInsert will insert the track in the first round in which its requester
+// doesn't have any track yet.
+func fairInsert(ts tracks.Tracks, t tracks.Track) tracks.Tracks {
+	u := requester(t)
+	rounds := make(map[string]int)
+	for _, tr := range ts {
+		rounds[requester(tr)]++
+	}
+	round := rounds[u]
+
+	clear(rounds)
+	for i, tr := range ts {
+		r := requester(tr)
+		if rounds[r] > round {
+			return append(ts[:i], append(tracks.Tracks{t}, ts[i:]...)...)
+		}
+		rounds[r]++
+	}
+	return append(ts, t)
+}
+
+// fairLayout will reorder the tracks in rounds, the requesters taking turns in
+// the order they first appear.
+func fairLayout(ts tracks.Tracks) tracks.Tracks {
+	var order []string
+	byUser := make(map[string]tracks.Tracks)
+	for _, t := range ts {
+		u := requester(t)
+		if _, ok := byUser[u]; !ok {
+			order = append(order, u)
+		}
+		byUser[u] = append(byUser[u], t)
+	}
+
+	out := make(tracks.Tracks, 0, len(ts))
+	for round := 0; len(out) < len(ts); round++ {
+		for _, u := range order {
+			if round < len(byUser[u]) {
+				out = append(out, byUser[u][round])
+			}
+		}
+	}
+	return out
+}
+
+// relayout will lay the upcoming tracks out in rounds again in fair mode, once
+// tracks were placed explicitly. Like with Prepend, a placed track makes its
+// requester take their turn earlier and comes first among their tracks, but
+// the rounds are kept. The lock must be held.
+func (q *Queue) relayout() {
+	if q.state.fair() {
+		i := q.upcoming()
+		q.tracks = append(q.tracks[:i:i], fairLayout(q.tracks[i:])...)
+	}
+}
+
+// upcoming returns the position of the first track that isn't playing. The
+// lock must be held.
+func (q *Queue) upcoming() int {
+	if q.state.playing() && len(q.tracks) != 0 {
+		return 1
+	}
+	return 0
+}
+
+// Rebalance will reorder the upcoming tracks in rounds of requesters.
+func (q *Queue) Rebalance() {
+	defer q.notify()
+	q.Lock()
+	defer q.Unlock()
+
+	i := q.upcoming()
+	q.tracks = append(q.tracks[:i:i], fairLayout(q.tracks[i:])...)
+}
+
+// turns returns the requesters of the upcoming tracks as displayed, in the
+// order they'll take their turn. The lock must be held.
+func (q *Queue) turns() []string {
+	var turns []string
+	seen := make(map[string]bool)
+	for _, t := range q.tracks[q.upcoming():] {
+		if u := requester(t); !seen[u] {
+			seen[u] = true
+			turns = append(turns, mention(t))
+		}
+	}
+	return turns
+}
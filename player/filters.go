@@ -0,0 +1,90 @@
+package player
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/depado/fox/models"
+)
+
+// Filter is a named ffmpeg audio filter chain that can be applied to the
+// player's output.
+type Filter struct {
+	Name        string
+	Description string
+	Chain       string
+	// Speed is the playback speed factor introduced by the chain, used to
+	// compute the position in the track from the position in the stream.
+	Speed float64
+}
+
+// Filters is the list of available filter presets.
+var Filters = []Filter{
+	{Name: "bassboost", Description: "Boost the low frequencies", Chain: "bass=g=10", Speed: 1},
+	{Name: "nightcore", Description: "Faster and higher pitched", Chain: "aresample=48000,asetrate=48000*1.25,aresample=48000", Speed: 1.25},
+	{Name: "vaporwave", Description: "Slower and lower pitched", Chain: "aresample=48000,asetrate=48000*0.8,aresample=48000", Speed: 0.8},
+	{Name: "8d", Description: "Sound rotating around your head", Chain: "apulsator=hz=0.125", Speed: 1},
+	{Name: "karaoke", Description: "Attempt to remove the vocals", Chain: "stereotools=mlev=0.03", Speed: 1},
+}
+
+// GetFilter will return the filter preset with the given name if any.
+func GetFilter(name string) (Filter, bool) {
+	for _, f := range Filters {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return Filter{}, false
+}
+
+// ActiveFilters returns the filter presets currently enabled for the guild.
+func (p *Player) ActiveFilters() []Filter {
+	var fs []Filter
+	for _, n := range p.GuildConf().Filters {
+		if f, ok := GetFilter(n); ok {
+			fs = append(fs, f)
+		}
+	}
+	return fs
+}
+
+// FiltersString returns a user-friendly representation of the active filters.
+func (p *Player) FiltersString() string {
+	if len(p.GuildConf().Filters) == 0 {
+		return "None"
+	}
+	return strings.Join(p.GuildConf().Filters, ", ")
+}
+
+// filterChain returns the ffmpeg filter chain combining all the active
+// filters, along with the resulting playback speed.
+func (p *Player) filterChain() (string, float64) {
+	chains := []string{}
+	speed := 1.0
+	for _, f := range p.ActiveFilters() {
+		chains = append(chains, f.Chain)
+		speed *= f.Speed
+	}
+	return strings.Join(chains, ","), speed
+}
+
+// SetFilters will enable the given filter presets, replacing the active ones,
+// save them in the guild conf and apply them to the currently playing track.
+// Passing no name disables all filters.
+func (p *Player) SetFilters(names ...string) error {
+	for _, n := range names {
+		if _, ok := GetFilter(n); !ok {
+			return fmt.Errorf("unknown filter %q", n)
+		}
+	}
+	if err := p.editConf(func(gc *models.Conf) { gc.Filters = names }); err != nil {
+		return fmt.Errorf("save guild conf: %w", err)
+	}
+	return nil
+}
+
+// scale converts a duration in the stream to a duration in the track
+func scale(d time.Duration, speed float64) time.Duration {
+	return time.Duration(float64(d) * speed)
+}
@@ -0,0 +1,150 @@
+package player
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"github.com/depado/fox/tracks"
+)
+
+// DefaultHistorySize is the number of played tracks kept when the guild
+// didn't configure it
+const DefaultHistorySize = 50
+
+// HistoryEntry is a track that was played at a given time
+type HistoryEntry struct {
+	Track    tracks.Track
+	PlayedAt time.Time
+}
+
+// History is a bounded list of the last played tracks, most recent first.
+type History struct {
+	sync.RWMutex
+
+	entries []HistoryEntry
+	size    int
+}
+
+// NewHistory returns a new history keeping at most size entries
+func NewHistory(size int) *History {
+	h := &History{}
+	h.SetSize(size)
+	return h
+}
+
+// SetSize will change the maximum number of entries kept, dropping the oldest
+// ones if needed. A size of zero or less will use the default size.
+func (h *History) SetSize(size int) {
+	h.Lock()
+	defer h.Unlock()
+
+	if size <= 0 {
+		size = DefaultHistorySize
+	}
+	h.size = size
+	if len(h.entries) > size {
+		h.entries = h.entries[:size]
+	}
+}
+
+// Push will add a track at the start of the history.
+func (h *History) Push(t tracks.Track, at time.Time) {
+	h.Lock()
+	defer h.Unlock()
+
+	h.entries = append([]HistoryEntry{{Track: t, PlayedAt: at}}, h.entries...)
+	if len(h.entries) > h.size {
+		h.entries = h.entries[:h.size]
+	}
+}
+
+// Pop will remove and return the most recent entry if any.
+func (h *History) Pop() (HistoryEntry, bool) {
+	h.Lock()
+	defer h.Unlock()
+
+	if len(h.entries) == 0 {
+		return HistoryEntry{}, false
+	}
+	e := h.entries[0]
+	h.entries = h.entries[1:]
+	return e, true
+}
+
+// Get will return the nth most recent entry, starting at 1.
+func (h *History) Get(n int) (HistoryEntry, bool) {
+	h.RLock()
+	defer h.RUnlock()
+
+	if n < 1 || n > len(h.entries) {
+		return HistoryEntry{}, false
+	}
+	return h.entries[n-1], true
+}
+
+// Len returns the number of entries in history.
+func (h *History) Len() int {
+	h.RLock()
+	defer h.RUnlock()
+
+	return len(h.entries)
+}
+
+// Entries returns a copy of all the entries, most recent first.
+func (h *History) Entries() []HistoryEntry {
+	h.RLock()
+	defer h.RUnlock()
+
+	es := make([]HistoryEntry, len(h.entries))
+	copy(es, h.entries)
+	return es
+}
+
+// HistoryPageSize is the number of entries displayed on a single page
+const HistoryPageSize = 10
+
+// Pages returns the number of pages needed to display the whole history.
+func (h *History) Pages() int {
+	n := h.Len()
+	if n == 0 {
+		return 1
+	}
+	return (n + HistoryPageSize - 1) / HistoryPageSize
+}
+
+// GenerateHistoryEmbed will generate the embed displaying the given page of
+// the history, starting at 1.
+func (h *History) GenerateHistoryEmbed(page int) *discordgo.MessageEmbed {
+	es := h.Entries()
+	pages := h.Pages()
+	if page < 1 {
+		page = 1
+	}
+	if page > pages {
+		page = pages
+	}
+
+	var body string
+	if len(es) == 0 {
+		body = "No track was played yet"
+	}
+	start := (page - 1) * HistoryPageSize
+	for i := start; i < len(es) && i < start+HistoryPageSize; i++ {
+		u, _ := es[i].Track.GetUser()
+		link := strings.TrimSuffix(es[i].Track.MarkdownLink(), "\n")
+		body += fmt.Sprintf("`%d.` %s\n<t:%d:R> • Added by %s\n", i+1, link, es[i].PlayedAt.Unix(), u)
+	}
+
+	return &discordgo.MessageEmbed{
+		Title:       "Playback History",
+		Description: body,
+		Color:       0xff5500,
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: fmt.Sprintf("Page %d/%d • %d tracks", page, pages, len(es)),
+		},
+	}
+}
@@ -0,0 +1,124 @@
+package player
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hako/durafmt"
+
+	"github.com/depado/fox/models"
+	"github.com/depado/fox/tracks"
+)
+
+// Admission is the outcome of adding tracks to the queue within the limits of
+// the guild. It counts the tracks that were left out for each limit.
+type Admission struct {
+	Limits models.Limits
+
+	Added    int
+	Playlist int
+	Duration int
+	User     int
+	Queue    int
+}
+
+// Dropped returns the number of tracks that were left out
+func (a Admission) Dropped() int {
+	return a.Playlist + a.Duration + a.User + a.Queue
+}
+
+// String explains why tracks were left out, one reason per line
+func (a Admission) String() string {
+	var rs []string
+	if a.Playlist > 0 {
+		rs = append(rs, fmt.Sprintf("**%d** tracks over the limit of %d tracks per playlist", a.Playlist, a.Limits.Playlist))
+	}
+	if a.Duration > 0 {
+		rs = append(rs, fmt.Sprintf("**%d** tracks longer than %s", a.Duration, durafmt.Parse(a.Limits.Duration).LimitFirstN(2)))
+	}
+	if a.User > 0 {
+		rs = append(rs, fmt.Sprintf("**%d** tracks over the limit of %d tracks per requester", a.User, a.Limits.User))
+	}
+	if a.Queue > 0 {
+		rs = append(rs, fmt.Sprintf("**%d** tracks over the limit of %d tracks in queue", a.Queue, a.Limits.Queue))
+	}
+	if len(rs) == 0 {
+		return ""
+	}
+	return "⚠️ Left out " + strings.Join(rs, ", ")
+}
+
+// AppendWithin will append the tracks at the end of queue like Append, leaving
+// out the tracks that exceed the given limits. The tracks are expected to come
+// from a single playlist when there is more than one.
+func (q *Queue) AppendWithin(l models.Limits, t ...tracks.Track) Admission {
+	defer q.notify()
+	q.Lock()
+	defer q.Unlock()
+
+	a, ok := q.admit(l, t)
+	q.appendTracks(ok...)
+	return a
+}
+
+// PrependWithin will add the tracks at the start of queue like Prepend,
+// leaving out the tracks that exceed the given limits.
+func (q *Queue) PrependWithin(l models.Limits, t ...tracks.Track) Admission {
+	defer q.notify()
+	q.Lock()
+	defer q.Unlock()
+
+	a, ok := q.admit(l, t)
+	q.prependTracks(ok...)
+	return a
+}
+
+// InsertWithin will insert the tracks so that the first one ends up at the
+// given position among the upcoming tracks, leaving out the tracks that
+// exceed the given limits.
+func (q *Queue) InsertWithin(l models.Limits, pos int, t ...tracks.Track) Admission {
+	defer q.notify()
+	q.Lock()
+	defer q.Unlock()
+
+	a, ok := q.admit(l, t)
+	q.insertAt(pos, ok...)
+	return a
+}
+
+// admit returns the tracks that can be added to the queue within the limits,
+// in order. The lock must be held.
+func (q *Queue) admit(l models.Limits, t tracks.Tracks) (Admission, tracks.Tracks) {
+	a := Admission{Limits: l}
+
+	if l.Playlist > 0 && len(t) > l.Playlist {
+		a.Playlist = len(t) - l.Playlist
+		t = t[:l.Playlist]
+	}
+
+	users := make(map[string]int)
+	for _, tr := range q.tracks {
+		users[requester(tr)]++
+	}
+	total := len(q.tracks)
+
+	var ok tracks.Tracks
+	for _, tr := range t {
+		u := requester(tr)
+		switch {
+		case l.Duration > 0 && time.Duration(tr.Duration())*time.Millisecond > l.Duration:
+			a.Duration++
+		case l.User > 0 && users[u] >= l.User:
+			a.User++
+		case l.Queue > 0 && total >= l.Queue:
+			a.Queue++
+		default:
+			ok = append(ok, tr)
+			users[u]++
+			total++
+		}
+	}
+	a.Added = len(ok)
+	return a, ok
+}
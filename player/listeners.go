@@ -0,0 +1,125 @@
+package player
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/hako/durafmt"
+)
+
+// VoiceChannel returns the ID of the voice channel the player is connected to,
+// or an empty string if it isn't connected.
+func (p *Player) VoiceChannel() string {
+	return p.Snapshot().Channel
+}
+
+// Listeners returns the IDs of the users currently in the voice channel the
+// player is connected to, bots excluded.
+func (p *Player) Listeners() []string {
+	ch := p.VoiceChannel()
+	if ch == "" || p.session == nil {
+		return nil
+	}
+	g, err := p.session.State.Guild(p.Guild)
+	if err != nil {
+		p.log.Err(err).Msg("unable to get guild from state")
+		return nil
+	}
+
+	p.session.State.RLock()
+	vss := make([]*discordgo.VoiceState, len(g.VoiceStates))
+	copy(vss, g.VoiceStates)
+	p.session.State.RUnlock()
+
+	var ls []string
+	for _, vs := range vss {
+		if vs.ChannelID != ch || vs.UserID == p.session.State.User.ID {
+			continue
+		}
+		m := vs.Member
+		if m == nil {
+			if m, err = p.session.State.Member(p.Guild, vs.UserID); err != nil {
+				continue
+			}
+		}
+		if m.User != nil && m.User.Bot {
+			continue
+		}
+		ls = append(ls, vs.UserID)
+	}
+	return ls
+}
+
+// UpdateListeners will pause the player when nobody is left listening in its
+// voice channel, and disconnect it once the leave timeout expires. If a
+// listener comes back before that, playback resumes automatically.
+func (p *Player) UpdateListeners() {
+	p.send(listenersCmd{count: len(p.Listeners())})
+}
+
+// leaveTimer disconnects the player once the leave timeout expires. It's only
+// accessed from the control goroutine.
+type leaveTimer struct {
+	*time.Timer
+}
+
+// updateListeners handles the number of listeners left in the voice channel.
+// It runs in the control goroutine.
+func (p *Player) updateListeners(pb *playback, n int) {
+	switch {
+	case n == 0 && p.leave == nil && pb != nil:
+		paused := p.Paused()
+		if !paused {
+			p.handle(pb, pauseCmd{})
+		}
+		timeout := p.conf.Bot.LeaveTimeout
+		lt := &leaveTimer{}
+		lt.Timer = time.AfterFunc(timeout, func() { p.send(leaveCmd{timer: lt}) })
+		p.leave = lt
+		p.update(func(s *State) { s.AutoPaused = !paused })
+		p.log.Debug().Str("event", "empty").Msg("voice channel is empty")
+		msg := fmt.Sprintf("I paused the music, and will leave the voice channel in %s unless someone comes back.", durafmt.Parse(timeout).LimitFirstN(2))
+		if paused {
+			msg = fmt.Sprintf("I will leave the voice channel in %s unless someone comes back.", durafmt.Parse(timeout).LimitFirstN(2))
+		}
+		p.SendNotice("⏸️ Everyone left", msg, "")
+	case n > 0 && p.leave != nil:
+		resume := p.Snapshot().AutoPaused
+		p.clearLeave()
+		p.log.Debug().Str("event", "listener").Msg("listener is back")
+		if resume {
+			p.handle(pb, resumeCmd{})
+			p.SendNotice("▶️ Welcome back!", "Playback resumed where it left off.", "")
+		} else {
+			p.SendNotice("👋 Welcome back!", "I'm staying, the player is still paused.", "")
+		}
+	}
+}
+
+// clearLeave will cancel the leave timer if any. It runs in the control
+// goroutine.
+func (p *Player) clearLeave() {
+	if p.leave != nil {
+		p.leave.Stop()
+		p.leave = nil
+	}
+	p.update(func(s *State) { s.AutoPaused = false })
+}
+
+// leaveEmpty is called when the given leave timer expires while nobody is
+// listening, and returns true when playback must stop. Timers that were
+// cancelled meanwhile are ignored. It runs in the control goroutine.
+func (p *Player) leaveEmpty(lt *leaveTimer) bool {
+	if lt != p.leave {
+		return false
+	}
+	p.clearLeave()
+	if !p.running {
+		return false
+	}
+	p.log.Debug().Str("event", "leave").Msg("leaving empty voice channel")
+	p.SendNotice("⏹️ Left the voice channel", fmt.Sprintf("Nobody was listening anymore. Use `%s play` to start again.", p.conf.Bot.Prefix), "")
+	end, _ := p.handle(nil, stopCmd{})
+	return end
+}
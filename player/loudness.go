@@ -0,0 +1,137 @@
+package player
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"sync"
+
+	"github.com/depado/fox/models"
+	"github.com/depado/fox/storage"
+)
+
+// loudnormStats is the output of the first pass of the loudnorm filter
+type loudnormStats struct {
+	InputI      string `json:"input_i"`
+	InputTP     string `json:"input_tp"`
+	InputLRA    string `json:"input_lra"`
+	InputThresh string `json:"input_thresh"`
+}
+
+// measureLoudness will run an analysis pass of ffmpeg's loudnorm filter over
+// the stream found at url and return its loudness measurements.
+func measureLoudness(url string) (*models.Loudness, error) {
+	var stderr bytes.Buffer
+
+	cmd := exec.Command(
+		"ffmpeg", "-hide_banner", "-nostats",
+		"-i", url, "-vn",
+		"-af", "loudnorm=print_format=json",
+		"-f", "null", "-",
+	)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("run analysis pass: %w", err)
+	}
+
+	// The measurements are printed as the last JSON object of the output
+	out := stderr.Bytes()
+	start, end := bytes.LastIndexByte(out, '{'), bytes.LastIndexByte(out, '}')
+	if start < 0 || end < start {
+		return nil, errors.New("no measurement found in analysis output")
+	}
+	ls := loudnormStats{}
+	if err := json.Unmarshal(out[start:end+1], &ls); err != nil {
+		return nil, fmt.Errorf("unmarshal measurements: %w", err)
+	}
+
+	l := &models.Loudness{}
+	for _, v := range []struct {
+		raw string
+		dst *float64
+	}{
+		{ls.InputI, &l.Integrated},
+		{ls.InputTP, &l.TruePeak},
+		{ls.InputLRA, &l.Range},
+		{ls.InputThresh, &l.Threshold},
+	} {
+		f, err := strconv.ParseFloat(v.raw, 64)
+		if err != nil {
+			return nil, fmt.Errorf("parse measurement: %w", err)
+		}
+		*v.dst = f
+	}
+	return l, nil
+}
+
+// loudness returns the stored loudness measurements of the track, or nil if
+// the track was never measured.
+func (p *Player) loudness(id string) *models.Loudness {
+	l, err := p.Storage.GetLoudness(id)
+	if err != nil {
+		if !errors.Is(err, storage.ErrLoudnessNotFound) {
+			p.log.Err(err).Msg("unable to get loudness")
+		}
+		return nil
+	}
+	return l
+}
+
+// measurements holds the tracks whose loudness is being measured
+type measurements struct {
+	sync.Mutex
+	ids map[string]bool
+}
+
+// measure will measure the loudness of the track in the background and store
+// it so that later plays of this track can skip the analysis pass. Nothing is
+// done if the track is already being measured.
+func (p *Player) measure(id, url string) {
+	p.measures.Lock()
+	defer p.measures.Unlock()
+	if p.measures.ids[id] {
+		return
+	}
+	if p.measures.ids == nil {
+		p.measures.ids = make(map[string]bool)
+	}
+	p.measures.ids[id] = true
+
+	go func() {
+		defer func() {
+			p.measures.Lock()
+			delete(p.measures.ids, id)
+			p.measures.Unlock()
+		}()
+
+		l, err := measureLoudness(url)
+		if err != nil {
+			p.log.Err(err).Str("track", id).Msg("unable to measure loudness")
+			return
+		}
+		if err := p.Storage.SaveLoudness(id, l); err != nil {
+			p.log.Err(err).Msg("unable to save loudness")
+		}
+	}()
+}
+
+// loudnormFilter returns the loudnorm filter targeting the guild's loudness.
+// When the track's measurements are known, the filter runs in linear mode
+// which preserves dynamics, otherwise it normalizes dynamically.
+func (p *Player) loudnormFilter(l *models.Loudness) string {
+	target := p.GuildConf().Loudness
+	if target == 0 {
+		return ""
+	}
+	f := fmt.Sprintf("loudnorm=I=%d:TP=-1.5:LRA=11", target)
+	if l != nil {
+		f += fmt.Sprintf(
+			":measured_I=%.2f:measured_TP=%.2f:measured_LRA=%.2f:measured_thresh=%.2f:linear=true",
+			l.Integrated, l.TruePeak, l.Range, l.Threshold,
+		)
+	}
+	return f
+}
@@ -0,0 +1,271 @@
+package player
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"github.com/depado/fox/models"
+	"github.com/depado/fox/storage"
+)
+
+const (
+	// panelRefresh is how often the panel is edited to update the progress bar
+	panelRefresh = 10 * time.Second
+	// panelThrottle is the minimum delay between two edits of the panel, so
+	// that bursts of events don't hit Discord's rate limits
+	panelThrottle = 2 * time.Second
+	// panelUpNext is the number of upcoming tracks shown in the panel
+	panelUpNext = 3
+)
+
+// panel is the live now-playing message of the guild. The panel message is
+// only posted, edited and deleted from the control goroutine, so that it
+// follows the changes of the guild conf in order.
+type panel struct {
+	models.Panel
+	loaded bool
+}
+
+// SetPanel will enable or disable the panel and save the guild conf. Disabling
+// it deletes the current panel message.
+func (p *Player) SetPanel(on bool) error {
+	if err := p.editConf(func(gc *models.Conf) { gc.Panel = on }); err != nil {
+		return fmt.Errorf("save guild conf: %w", err)
+	}
+	return nil
+}
+
+// RepinPanel will post a new panel message at the bottom of the text channel
+// and pin it, deleting the previous one.
+func (p *Player) RepinPanel() error {
+	done := make(chan error, 1)
+	if !p.send(repinCmd{done: done}) {
+		return ErrKilled
+	}
+	select {
+	case err := <-done:
+		return err
+	case <-p.quit:
+		return ErrKilled
+	}
+}
+
+// repinPanel will post a new panel message, deleting the previous one. It
+// runs in the control goroutine.
+func (p *Player) repinPanel() error {
+	p.deletePanel()
+	return p.postPanel()
+}
+
+// applyPanel will delete the panel once disabled, and move it when the text
+// channel changed. It runs in the control goroutine.
+func (p *Player) applyPanel(old, gc *models.Conf) {
+	switch {
+	case old.Panel && !gc.Panel:
+		p.deletePanel()
+	case gc.Panel && old.TextChannel != gc.TextChannel:
+		p.deletePanel()
+		if err := p.refreshPanel(true); err != nil {
+			p.log.Err(err).Msg("unable to move panel")
+		}
+	}
+}
+
+// runPanel will keep the panel up to date until the player is killed. The
+// panel is edited on every event, and periodically while a track is playing
+// so that the progress bar moves. The edits are made by the control goroutine.
+func (p *Player) runPanel() {
+	evs, unsubscribe := p.Subscribe()
+	defer unsubscribe()
+
+	tick := time.NewTicker(panelThrottle)
+	defer tick.Stop()
+
+	var dirty, create bool
+	var last time.Time
+	for {
+		select {
+		case <-p.quit:
+			return
+		case ev := <-evs:
+			dirty = true
+			// A new panel is only posted when something starts playing
+			if ev.Type == TrackStarted {
+				create = true
+			}
+		case now := <-tick.C:
+			if !p.GuildConf().Panel {
+				dirty, create = false, false
+				continue
+			}
+			st := p.Snapshot()
+			if !dirty && (!st.Playing || st.Paused || now.Sub(last) < panelRefresh) {
+				continue
+			}
+			if !p.send(panelCmd{create: create}) {
+				return
+			}
+			dirty, create, last = false, false, now
+		}
+	}
+}
+
+// refreshPanel will edit the panel message, and post it if it doesn't exist
+// and create is true. It runs in the control goroutine.
+func (p *Player) refreshPanel(create bool) error {
+	if !p.GuildConf().Panel {
+		return nil
+	}
+	p.loadPanel()
+	ch := p.GuildConf().TextChannel
+	if ch == "" {
+		return nil
+	}
+	if p.panel.Message != "" && p.panel.Channel == ch {
+		_, err := p.session.ChannelMessageEditEmbed(p.panel.Channel, p.panel.Message, p.GeneratePanelEmbed())
+		if err == nil {
+			return nil
+		}
+		if !unknownMessage(err) {
+			return fmt.Errorf("edit panel: %w", err)
+		}
+		// Someone deleted the panel message
+		p.forgetPanel()
+	}
+	if !create {
+		return nil
+	}
+	p.deletePanel()
+	return p.postPanel()
+}
+
+// postPanel will send a new panel message in the text channel and pin it. It runs in
+// the control goroutine.
+func (p *Player) postPanel() error {
+	ch := p.GuildConf().TextChannel
+	if ch == "" {
+		return fmt.Errorf("no text channel configured")
+	}
+
+	m, err := p.session.ChannelMessageSendEmbed(ch, p.GeneratePanelEmbed())
+	if err != nil {
+		return fmt.Errorf("send panel: %w", err)
+	}
+	// Pinning requires the manage messages permission, the panel still works
+	// without it
+	if err := p.session.ChannelMessagePin(ch, m.ID); err != nil {
+		p.log.Warn().Err(err).Msg("unable to pin panel")
+	}
+
+	p.panel.Panel = models.Panel{Channel: ch, Message: m.ID}
+	if err := p.Storage.SavePanel(p.Guild, &p.panel.Panel); err != nil {
+		p.log.Err(err).Msg("unable to save panel")
+	}
+	return nil
+}
+
+// deletePanel will delete the panel message if any. It runs in the control
+// goroutine.
+func (p *Player) deletePanel() {
+	p.loadPanel()
+	if p.panel.Message == "" {
+		return
+	}
+	if err := p.session.ChannelMessageDelete(p.panel.Channel, p.panel.Message); err != nil && !unknownMessage(err) {
+		p.log.Err(err).Msg("unable to delete panel")
+	}
+	p.forgetPanel()
+}
+
+// forgetPanel will clear the panel message, in memory and in storage. It runs in
+// the control goroutine.
+func (p *Player) forgetPanel() {
+	p.panel.Panel = models.Panel{}
+	if err := p.Storage.DeletePanel(p.Guild); err != nil {
+		p.log.Err(err).Msg("unable to delete saved panel")
+	}
+}
+
+// loadPanel will fetch the panel message saved before a restart, once. It runs in
+// the control goroutine.
+func (p *Player) loadPanel() {
+	if p.panel.loaded {
+		return
+	}
+	p.panel.loaded = true
+	pa, err := p.Storage.GetPanel(p.Guild)
+	if err != nil {
+		if !errors.Is(err, storage.ErrPanelNotFound) {
+			p.log.Err(err).Msg("unable to fetch panel")
+		}
+		return
+	}
+	p.panel.Panel = *pa
+}
+
+// unknownMessage returns whether Discord answered that the message doesn't
+// exist anymore
+func unknownMessage(err error) bool {
+	var rerr *discordgo.RESTError
+	return errors.As(err, &rerr) && rerr.Message != nil && rerr.Message.Code == discordgo.ErrCodeUnknownMessage
+}
+
+// GeneratePanelEmbed will generate the embed of the panel, showing the
+// current track, the state of the player and the upcoming tracks.
+func (p *Player) GeneratePanelEmbed() *discordgo.MessageEmbed {
+	st := p.Snapshot()
+	e := p.GenerateNowPlayingEmbed(true)
+	if e == nil {
+		e = &discordgo.MessageEmbed{
+			Title:       "Nothing is playing",
+			Description: fmt.Sprintf("Add tracks with the `%sadd` command and start playing with `%splay`", p.conf.Bot.Prefix, p.conf.Bot.Prefix),
+			Color:       0xff5500,
+		}
+	}
+
+	state := "⏹️ Stopped"
+	switch {
+	case st.AutoPaused:
+		state = "⏸️ Paused, nobody is listening"
+	case st.Paused:
+		state = "⏸️ Paused"
+	case st.Playing:
+		state = "▶️ Playing"
+	}
+	e.Fields = append(e.Fields, &discordgo.MessageEmbedField{
+		Name:   "State",
+		Value:  fmt.Sprintf("%s • 🔊 %d%%", state, st.Volume*100/256),
+		Inline: false,
+	})
+
+	// The first track in queue is the one playing
+	first := 0
+	if st.Playing {
+		first = 1
+	}
+	var next string
+	for i := first; i < first+panelUpNext; i++ {
+		t := p.Queue.Peek(i)
+		if t == nil {
+			break
+		}
+		next += fmt.Sprintf("`%s` %s", fmtDuration(time.Duration(t.Duration())*time.Millisecond), t.MarkdownLink())
+	}
+	if left := p.Queue.Len() - first - panelUpNext; left > 0 {
+		next += fmt.Sprintf("And **%d** other tracks", left)
+	}
+	if next == "" {
+		next = "There is currently no track in queue"
+	}
+	e.Fields = append(e.Fields, &discordgo.MessageEmbedField{
+		Name:   "Up next",
+		Value:  next,
+		Inline: false,
+	})
+	e.Timestamp = time.Now().Format(time.RFC3339)
+
+	return e
+}
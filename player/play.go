@@ -4,55 +4,116 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"strings"
 	"time"
 
 	"github.com/jonas747/dca"
+
+	"github.com/depado/fox/models"
+	"github.com/depado/fox/tracks"
 )
 
 // Play will start to play the current queue
 func (p *Player) Play() {
-	if p.Playing() {
-		return
-	}
-	go func() {
-		p.audio.Lock()
-		defer p.audio.Unlock()
+	p.send(playCmd{})
+}
 
-		for {
-			tracklen := p.Queue.Len()
-			if tracklen == 0 {
-				p.SendNotice("Nothing left to play!", fmt.Sprintf("You can give me more by using the `%s` command!", p.conf.Bot.Prefix), "")
-				if err := p.Disconnect(); err != nil {
-					p.log.Err(err).Msg("unable to disconnect from voice channel")
-				}
-				return
-			}
+// playQueue will play the queue until it's empty or the player is stopped. It
+// runs in the control goroutine.
+func (p *Player) playQueue() {
+	p.running = true
+	defer func() { p.running = false }()
+	// Nobody is waited for and nothing is put to sleep once playback ends
+	defer p.clearLeave()
+	defer p.clearSleep()
+	p.update(func(s *State) { s.Stopped = false })
+	// The voice channel override only lasts for this playing session
+	defer func() { p.channel = "" }()
 
-			t := p.Queue.Get()
-			if t == nil {
-				continue
-			}
+	for {
+		select {
+		case <-p.quit:
+			return
+		default:
+		}
 
-			stream, err := t.StreamURL()
-			if err != nil {
-				p.log.Err(err).Msg("unable to find a suitable stream")
-				p.Queue.Pop()
+		tracklen := p.Queue.Len()
+		if tracklen == 0 {
+			if p.autoplay() {
 				continue
 			}
-
-			if err := p.Read(stream); err != nil {
-				p.log.Err(err).Msg("unable to read stream")
+			if !p.Stopped() {
+				p.SendNotice("Nothing left to play!", fmt.Sprintf("You can give me more by using the `%s` command!", p.conf.Bot.Prefix), "")
+			}
+			if err := p.Disconnect(); err != nil {
+				p.log.Err(err).Msg("unable to disconnect from voice channel")
 			}
+			return
+		}
 
-			if p.Stopped() {
-				if err := p.Disconnect(); err != nil {
-					p.log.Err(err).Msg("unable to disconnect from voice channel")
-				}
-				return
+		t := p.Queue.Get()
+		if t == nil {
+			continue
+		}
+
+		started := time.Now()
+		err := p.playTrack(t)
+		if err != nil {
+			p.log.Err(err).Msg("unable to play track")
+		} else if p.announced {
+			p.emit(Event{Type: TrackEnded, Track: t, Skipped: p.skipped || p.Stopped()})
+		}
+
+		if p.Stopped() {
+			p.skipped, p.rewind = false, false
+			p.discardPreload()
+			if err := p.Disconnect(); err != nil {
+				p.log.Err(err).Msg("unable to disconnect from voice channel")
 			}
+			return
+		}
+		// Never loop on a track that failed to play
+		if err != nil {
+			p.discardPreload()
 			p.Queue.Pop()
+			continue
 		}
-	}()
+		p.next(t, started)
+		if p.trackEnded() {
+			p.discardPreload()
+			p.SendNotice("💤 Good night", "That was the last track before going to sleep, playback stopped", "")
+			if err := p.Disconnect(); err != nil {
+				p.log.Err(err).Msg("unable to disconnect from voice channel")
+			}
+			return
+		}
+	}
+}
+
+// next will advance the queue once a track has been played, according to the
+// loop mode, and record the track in history. A skipped track is never
+// replayed.
+func (p *Player) next(t tracks.Track, started time.Time) {
+	skipped, rewind := p.skipped, p.rewind
+	p.skipped, p.rewind = false, false
+	loop := p.Loop()
+
+	if rewind {
+		// The previous track was inserted right after this one
+		p.Queue.Swap(0, 1)
+		return
+	}
+	if loop != LoopTrack || skipped {
+		p.History.Push(t, started)
+	}
+
+	switch {
+	case loop == LoopQueue:
+		p.Queue.Loop()
+	case loop == LoopTrack && !skipped:
+	default:
+		p.Queue.Pop()
+	}
 }
 
 func (p *Player) onReadStart() error {
@@ -64,11 +125,13 @@ func (p *Player) onReadStart() error {
 	if err := p.voice.Speaking(true); err != nil {
 		return fmt.Errorf("failed setting voice to speaking: %w", err)
 	}
-	p.Stats = &Stats{}
-	p.state.Lock()
-	defer p.state.Unlock()
-	p.state.Playing = true
-	p.state.Stopped = false
+	p.update(func(s *State) {
+		s.Playing = true
+		s.Stopped = false
+		s.Paused = false
+		s.Position = p.startAt
+		s.stats = &Stats{}
+	})
 	return nil
 }
 
@@ -78,42 +141,277 @@ func (p *Player) onReadEnd() {
 			p.log.Err(err).Msg("unable to set speaking to false")
 		}
 	}
-	p.Stats = nil
-	p.state.Lock()
-	defer p.state.Unlock()
-	p.state.Playing = false
+	p.update(func(s *State) {
+		s.Playing = false
+		s.Position = 0
+		s.stats = nil
+	})
 	p.stream = nil
 	p.encode = nil
 }
 
-func (p *Player) Read(url string) error {
+// encodeOptions returns the encoding options used to start a new encoding
+// session. The loudness measurements of the track are used for normalization
+// if known. The encoder seeks in its input, see newEncoder.
+func (p *Player) encodeOptions(l *models.Loudness) *dca.EncodeOptions {
+	chain, _ := p.filterChain()
+	if ln := p.loudnormFilter(l); ln != "" {
+		chain = strings.TrimSuffix(ln+","+chain, ",")
+	}
+	opts := *dca.StdEncodeOptions
+	opts.RawOutput = true
+	opts.Bitrate = p.Bitrate()
+	opts.Volume = p.Volume()
+	opts.AudioFilter = chain
+	if f := p.fadeFilter(); f != "" {
+		opts.AudioFilter = strings.TrimPrefix(opts.AudioFilter+","+f, ",")
+	}
+	return &opts
+}
+
+// primedReader is an OpusReader that will return an already read frame before
+// reading from the underlying encoding session.
+type primedReader struct {
+	dca.OpusReader
+	first []byte
+}
+
+func (r *primedReader) OpusFrame() ([]byte, error) {
+	if r.first != nil {
+		f := r.first
+		r.first = nil
+		return f, nil
+	}
+	return r.OpusReader.OpusFrame()
+}
+
+// newSession will create a new encoding session for the given URL starting at
+// the given position. When primed is true, this function blocks until the
+// encoding session has produced its first frame.
+func (p *Player) newSession(url string, pos time.Duration, opts *dca.EncodeOptions, primed bool) (*encoder, dca.OpusReader, error) {
+	encode, err := newEncoder(url, pos, opts)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed creating the encoding session: %w", err)
+	}
+	if !primed {
+		return encode, encode, nil
+	}
+
+	first, err := encode.OpusFrame()
+	if err != nil {
+		encode.Cleanup()
+		return nil, nil, fmt.Errorf("prime encoding session: %w", err)
+	}
+	return encode, &primedReader{OpusReader: encode, first: first}, nil
+}
+
+// swapStream will stream the given source to the voice connection in place of
+// the current one. The returned channel will receive the streaming session's
+// outcome.
+func (p *Player) swapStream(encode *encoder, src dca.OpusReader, pos time.Duration) chan error {
+	p.stopStream()
+
+	// Buffered so that a discarded streaming session never blocks on send
+	done := make(chan error, 1)
+	p.encode = encode
+	p.offset = pos
+	_, p.speed = p.filterChain()
+	p.stream = dca.NewStream(src, p.voice, done)
+	if p.Paused() {
+		p.stream.SetPaused(true)
+	}
+	p.update(func(s *State) { s.Position = pos })
+	return done
+}
+
+// startStream will create a new encoding session for the given URL starting at
+// the given position, and stream it to the voice connection in place of the
+// current one. When seamless is true the current stream keeps playing until the
+// new encoding session has produced its first frame.
+func (p *Player) startStream(url string, pos time.Duration, seamless bool) (chan error, error) {
+	started := time.Now()
+	opts := p.encodeOptions(p.measured)
+	encode, src, err := p.newSession(url, pos, opts, seamless)
+	if err != nil {
+		return nil, err
+	}
+	if seamless {
+		// The current stream kept playing while the new session started
+		_, speed := p.filterChain()
+		pos += scale(skipFrames(src, time.Since(started), opts.FrameDuration), speed)
+	}
+	return p.swapStream(encode, src, pos), nil
+}
+
+// stopStream will discard the current streaming and encoding sessions if any.
+func (p *Player) stopStream() {
+	if p.stream != nil {
+		p.stream.SetPaused(true)
+	}
+	if p.encode != nil {
+		p.encode.Cleanup()
+	}
+}
+
+// position returns the current playback position in the playing track. It
+// must only be called from the control goroutine, see Position otherwise.
+func (p *Player) position() time.Duration {
+	if p.stream == nil {
+		return 0
+	}
+	return p.offset + scale(p.stream.PlaybackPosition(), p.speed)
+}
+
+// streamTTL is how long a stream URL is reused when restarting a stream, as
+// the URLs provided by SoundCloud expire
+const streamTTL = time.Minute
+
+// playback is the track being streamed by the control goroutine
+type playback struct {
+	track tracks.Track
+	url   string
+	// resolved is when the stream URL was resolved
+	resolved time.Time
+	duration time.Duration
+	done     chan error
+	// requested is true once the next track is being prepared
+	requested bool
+	prepared  chan *preload
+}
+
+// resolveAgain will resolve the stream URL of the track again if it was resolved
+// more than streamTTL ago, and return the URL to use and when it was resolved.
+// The previous URL is kept if it can't be resolved.
+func (p *Player) resolveAgain(t tracks.Track, url string, resolved time.Time) (string, time.Time) {
+	if time.Since(resolved) <= streamTTL {
+		return url, resolved
+	}
+	u, err := t.StreamURL()
+	if err != nil {
+		p.log.Err(err).Msg("unable to resolve stream again, reusing the previous one")
+		return url, resolved
+	}
+	return u, time.Now()
+}
+
+// restart will restart the stream of the given playback at the given position.
+// When seamless is true, the current stream keeps playing if the new one can't
+// be started.
+func (p *Player) restart(pb *playback, pos time.Duration, seamless bool) error {
+	p.log.Debug().Str("event", "restart").Dur("position", pos).Bool("seamless", seamless).Msg("restarting stream")
+	pb.url, pb.resolved = p.resolveAgain(pb.track, pb.url, pb.resolved)
+	done, err := p.startStream(pb.url, pos, seamless)
+	if err != nil {
+		if !seamless {
+			return err
+		}
+		p.log.Err(err).Msg("unable to restart stream, keeping the current one")
+		return nil
+	}
+	pb.done = done
+	// The preloaded session may have been encoded with outdated options
+	p.discardPreload()
+	pb.requested = false
+	st := p.Stats()
+	st.Lock()
+	st.PlaybackPosition = p.offset
+	st.TimeAxis = nil
+	st.BiteRateAxis = nil
+	st.Unlock()
+	return nil
+}
+
+// dropCrossfade will discard the preloaded session if it's a crossfade, since
+// it would replay the tail of the current track.
+func (p *Player) dropCrossfade() {
+	if p.preloaded != nil && p.preloaded.fade > 0 {
+		p.discardPreload()
+	}
+}
+
+// Read will stream the given track to the voice channel until it ends, the
+// player is stopped or the track is skipped, handling the commands sent to the
+// player meanwhile. If a preloaded session is given, it is used instead of
+// starting a new encoding session.
+// When the stream fails, the position reached is kept so that the track can
+// be resumed.
+func (p *Player) Read(t tracks.Track, url string, pl *preload) (rerr error) {
 	var err error
 
 	if err = p.onReadStart(); err != nil {
+		if pl != nil {
+			pl.discard()
+		}
 		return fmt.Errorf("unable to start playing: %w", err)
 	}
 	defer p.onReadEnd()
+	// Recovering from a failure doesn't start the track again
+	if !p.announced {
+		p.announced = true
+		p.resetVotes()
+		p.emit(Event{Type: TrackStarted, Track: t})
+	}
 
-	opts := dca.StdEncodeOptions
-	opts.RawOutput = true
-	opts.Bitrate = 120
-	opts.Volume = p.Volume()
+	// A restored or recovering session starts where it left off
+	start := p.startAt
+	p.startAt = 0
+	defer func() {
+		if rerr == nil {
+			return
+		}
+		if p.stream != nil {
+			p.startAt = p.position()
+		} else {
+			p.startAt = start
+		}
+	}()
 
-	p.encode, err = dca.EncodeFile(url, opts)
-	if err != nil {
-		return fmt.Errorf("failed creating the encoding session: %w", err)
+	p.measured = nil
+	if p.GuildConf().Loudness != 0 {
+		if pl != nil {
+			p.measured = pl.loudness
+		} else if p.measured = p.loudness(t.ID()); p.measured == nil {
+			// Normalize dynamically for now, the measurement will be used
+			// the next time this track is played
+			p.measure(t.ID(), url)
+		}
 	}
-	defer p.encode.Cleanup()
 
-	done := make(chan error)
-	p.stream = dca.NewStream(p.encode, p.voice, done)
+	pb := &playback{
+		track:    t,
+		url:      url,
+		resolved: time.Now(),
+		duration: time.Duration(t.Duration()) * time.Millisecond,
+		prepared: make(chan *preload, 1),
+	}
+	if pl != nil {
+		pb.done = p.swapStream(pl.encode, &primedReader{OpusReader: pl.encode, first: pl.first}, 0)
+	} else if pb.done, err = p.startStream(url, start, false); err != nil {
+		return err
+	}
+	defer p.stopStream()
+
+	// Prepare the next track in the background when nearing the end
+	quit := make(chan struct{})
+	defer close(quit)
+	var faded bool
+
 	tc := time.NewTicker(5 * time.Second)
 	defer tc.Stop()
+	tt := time.NewTicker(250 * time.Millisecond)
+	defer tt.Stop()
 
 	for {
 		select {
-		case err := <-done:
+		case err := <-pb.done:
 			if err != nil && err == io.EOF {
+				// ffmpeg stops producing frames when it fails mid-track
+				if ferr := p.encode.Error(); ferr != nil {
+					return fmt.Errorf("encoding session: %w", ferr)
+				}
+				if pb.duration-p.position() > endTolerance {
+					return ErrPrematureEnd
+				}
 				return nil
 			}
 			if errors.Is(err, dca.ErrVoiceConnClosed) {
@@ -127,26 +425,59 @@ func (p *Player) Read(url string) error {
 					p.voice = nil
 					return err
 				}
-				p.stream = dca.NewStream(p.encode, p.voice, done)
+				pb.done = make(chan error, 1)
+				p.offset = p.position()
+				p.stream = dca.NewStream(p.encode, p.voice, pb.done)
 				p.log.Info().Msg("voice reconnected")
 				continue
 			}
 			return fmt.Errorf("reading stream: %w", err)
-		case <-p.stop:
-			p.log.Debug().Str("event", "stop").Msg("stopping player")
+		case c := <-p.commands:
+			end, err := p.handle(pb, c)
+			if err != nil {
+				return fmt.Errorf("seek: %w", err)
+			}
+			if end {
+				p.log.Debug().Str("event", "end").Msg("ending track")
+				return nil
+			}
+		case <-p.quit:
+			p.update(func(s *State) { s.Stopped = true })
 			return nil
+		case np := <-pb.prepared:
+			p.discardPreload()
+			p.preloaded = np
+		case <-tt.C:
+			pos := p.position()
+			p.update(func(s *State) { s.Position = pos })
+			rem := pb.duration - pos
+			if !pb.requested && rem <= preloadAhead+p.crossfade() {
+				if next := p.upcoming(); next != nil {
+					pb.requested = true
+					go p.prepare(t, pb.url, pb.resolved, next, pb.prepared, quit)
+				}
+			}
+			if !faded && rem <= sleepFade && p.lastTrack() {
+				faded = true
+				go p.fadeOut()
+			}
+			if p.preloaded != nil && p.preloaded.fade > 0 && rem <= p.preloaded.fade {
+				p.log.Debug().Str("event", "crossfade").Msg("handing over to the next track")
+				return nil
+			}
 		case <-tc.C:
-			d := p.stream.PlaybackPosition()
+			d := p.position()
 			s := p.encode.Stats()
-			p.Stats.Lock()
-			p.Stats.PlaybackPosition = d
-			p.Stats.Bitrate = s.Bitrate
-			p.Stats.Duration = s.Duration
-			p.Stats.Speed = s.Speed
-			p.Stats.Size = s.Size
-			p.Stats.TimeAxis = append(p.Stats.TimeAxis, float64(s.Duration))
-			p.Stats.BiteRateAxis = append(p.Stats.BiteRateAxis, float64(s.Bitrate))
-			p.Stats.Unlock()
+			st := p.Stats()
+			st.Lock()
+			st.PlaybackPosition = d
+			st.Bitrate = s.Bitrate
+			st.Duration = s.Duration
+			st.Speed = s.Speed
+			st.Size = s.Size
+			st.TimeAxis = append(st.TimeAxis, float64(s.Duration))
+			st.BiteRateAxis = append(st.BiteRateAxis, float64(s.Bitrate))
+			st.Unlock()
 		}
 	}
 }
@@ -1,15 +1,19 @@
 package player
 
 import (
+	"context"
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/jonas747/dca"
 	"github.com/rs/zerolog"
+	"go.uber.org/fx"
 
 	"github.com/depado/fox/cmd"
 	"github.com/depado/fox/models"
+	"github.com/depado/fox/soundcloud"
 	"github.com/depado/fox/storage"
 )
 
@@ -17,6 +21,9 @@ import (
 type Players struct {
 	sync.RWMutex
 	Players map[string]*Player
+
+	sp     *soundcloud.SoundCloudProvider
+	events *Events
 }
 
 // GetPlayer will get the player associated with the guild ID if any
@@ -31,6 +38,18 @@ func (p *Players) GetPlayer(guildID string) *Player {
 	return pl
 }
 
+// All returns all the guild players
+func (p *Players) All() []*Player {
+	p.RLock()
+	defer p.RUnlock()
+
+	pls := make([]*Player, 0, len(p.Players))
+	for _, pl := range p.Players {
+		pls = append(pls, pl)
+	}
+	return pls
+}
+
 // Create will create a new player and associate it with the guild
 func (p *Players) Create(s *discordgo.Session, conf *cmd.Conf, log zerolog.Logger, guild string, storage *storage.BoltStorage, gc *models.Conf) error {
 	p.Lock()
@@ -39,7 +58,7 @@ func (p *Players) Create(s *discordgo.Session, conf *cmd.Conf, log zerolog.Logge
 	if _, ok := p.Players[guild]; ok {
 		return fmt.Errorf("guild already has an associated player")
 	}
-	play, err := NewPlayer(s, conf, log.With().Str("guild", guild).Logger(), guild, storage, gc)
+	play, err := NewPlayer(s, conf, log.With().Str("guild", guild).Logger(), guild, storage, p.sp, p.events, gc)
 	if err != nil {
 		return fmt.Errorf("create player: %w", err)
 	}
@@ -47,69 +66,129 @@ func (p *Players) Create(s *discordgo.Session, conf *cmd.Conf, log zerolog.Logge
 	return nil
 }
 
-// Kill will kill all the players
+// Kill will save the session of all the players and kill them
 func (p *Players) Kill() {
 	p.Lock()
 	defer p.Unlock()
 
 	for _, pl := range p.Players {
+		if err := pl.Save(); err != nil {
+			pl.log.Err(err).Msg("unable to save session")
+		}
 		pl.Kill()
 	}
 }
 
-// NewPlayers instantiates the player map
-func NewPlayers() *Players {
-	return &Players{
+// NewPlayers instantiates the player map and periodically saves the players'
+// sessions
+func NewPlayers(lc fx.Lifecycle, sp *soundcloud.SoundCloudProvider) *Players {
+	p := &Players{
 		Players: make(map[string]*Player),
+		sp:      sp,
+		events:  NewEvents(),
 	}
+
+	quit := make(chan struct{})
+	lc.Append(fx.Hook{
+		OnStart: func(c context.Context) error {
+			go p.autosave(quit)
+			return nil
+		},
+		OnStop: func(c context.Context) error {
+			close(quit)
+			return nil
+		},
+	})
+	return p
 }
 
 type PlaySession struct {
 }
 
+// killTimeout is how long killing a player waits for it to disconnect
+const killTimeout = 5 * time.Second
+
 // Player is the struct in charge of connecting to voice channels and streaming
-// tracks to them.
+// tracks to them. A single control goroutine handles the commands sent to the
+// player, and is the only one touching the voice connection and the encoding
+// and streaming sessions. Its state is published and can be read at any time.
 type Player struct {
 	Queue   *Queue
+	History *History
 	state   *State
 	Guild   string
-	Conf    *models.Conf
 	Storage *storage.BoltStorage
 
-	log     zerolog.Logger
-	conf    *cmd.Conf
-	stop    chan bool
-	encode  *dca.EncodeSession
-	stream  *dca.StreamingSession
-	voice   *discordgo.VoiceConnection
-	session *discordgo.Session
-	audio   sync.RWMutex
-	Stats   *Stats
+	log      zerolog.Logger
+	conf     *cmd.Conf
+	commands chan command
+	quit     chan struct{}
+	done     chan struct{}
+	kill     sync.Once
+	editing  sync.Mutex
+	session  *discordgo.Session
+	sleep    sleeper
+	votes    votes
+	measures measurements
+	sp       *soundcloud.SoundCloudProvider
+	events   *Events
+	hub      *Events
+
+	// The following fields are only accessed from the control goroutine
+	panel     panel
+	offset    time.Duration
+	speed     float64
+	encode    *encoder
+	stream    *dca.StreamingSession
+	voice     *discordgo.VoiceConnection
+	channel   string
+	startAt   time.Duration
+	running   bool
+	skipped   bool
+	rewind    bool
+	preloaded *preload
+	measured  *models.Loudness
+	announced bool
+	leave     *leaveTimer
 }
 
 // NewPlayer will create a new player from scratch using the provided
-// arguments
-func NewPlayer(s *discordgo.Session, conf *cmd.Conf, log zerolog.Logger, guildID string, storage *storage.BoltStorage, gc *models.Conf) (*Player, error) {
+// arguments, and start its control goroutine
+func NewPlayer(s *discordgo.Session, conf *cmd.Conf, log zerolog.Logger, guildID string, storage *storage.BoltStorage, sp *soundcloud.SoundCloudProvider, hub *Events, gc *models.Conf) (*Player, error) {
 	st := NewState()
+	st.conf = gc
 	p := &Player{
-		state:   st,
-		Queue:   NewQueue(st),
-		Storage: storage,
-		Guild:   guildID,
-		Conf:    gc,
-		session: s,
-		log:     log.With().Str("component", "player").Logger(),
-		conf:    conf,
-		stop:    make(chan bool),
+		state:    st,
+		History:  NewHistory(gc.QueueHistory),
+		Storage:  storage,
+		Guild:    guildID,
+		session:  s,
+		sp:       sp,
+		log:      log.With().Str("component", "player").Logger(),
+		conf:     conf,
+		commands: make(chan command, commandBuffer),
+		quit:     make(chan struct{}),
+		done:     make(chan struct{}),
+		events:   NewEvents(),
+		hub:      hub,
 	}
+	p.Queue = NewQueue(st, func() { p.emit(Event{Type: QueueChanged}) })
+	go p.run()
+	go p.runPanel()
 
 	return p, nil
 }
 
+// Kill will stop the player and its control goroutine, waiting for it to
+// disconnect from the voice channel.
 func (p *Player) Kill() {
 	p.log.Debug().Msg("kill called")
-	p.Stop()
-	p.Disconnect() // nolint:errcheck
+	p.kill.Do(func() { close(p.quit) })
+	select {
+	case <-p.done:
+	case <-time.After(killTimeout):
+		p.log.Warn().Msg("player didn't stop in time")
+	}
 }
 
 // Disconnect will disconnect the player from the currently connected voice
@@ -120,6 +199,8 @@ func (p *Player) Disconnect() error {
 			return fmt.Errorf("disconnect voice channel: %w", err)
 		}
 		p.voice = nil
+		p.update(func(s *State) { s.Channel = "" })
+		p.emit(Event{Type: Disconnected})
 	}
 	return nil
 }
@@ -127,13 +208,68 @@ func (p *Player) Disconnect() error {
 // Connect will connect the player to the voice channel.
 func (p *Player) Connect() error {
 	if p.session != nil {
-		voice, err := p.session.ChannelVoiceJoin(p.Conf.ID, p.Conf.VoiceChannel, false, true)
+		ch := p.voiceChannel()
+		voice, err := p.session.ChannelVoiceJoin(p.Guild, ch, false, true)
 		if err != nil {
 			return fmt.Errorf("unable to establish connection to vocal channel: %w", err)
 		}
 		p.voice = voice
+		p.updateBitrate(ch)
+		p.update(func(s *State) { s.Channel = ch })
 	} else {
 		return fmt.Errorf("unable to connect to vocal channel: no discordgo session active")
 	}
 	return nil
 }
+
+// voiceChannel returns the voice channel the player should connect to.
+func (p *Player) voiceChannel() string {
+	if p.channel != "" {
+		return p.channel
+	}
+	return p.GuildConf().VoiceChannel
+}
+
+// SetVoiceChannel will make the player join the given voice channel instead of
+// the one configured for the guild, until playback ends. If the player is
+// already connected, it is moved to this channel without interrupting
+// playback.
+func (p *Player) SetVoiceChannel(ch string) error {
+	done := make(chan error, 1)
+	if !p.send(voiceCmd{channel: ch, done: done}) {
+		return ErrKilled
+	}
+	select {
+	case err := <-done:
+		return err
+	case <-p.quit:
+		return ErrKilled
+	}
+}
+
+// moveVoice will set the voice channel override and move the player to it.
+func (p *Player) moveVoice(pb *playback, ch string) error {
+	p.channel = ch
+	return p.switchChannel(pb, ch)
+}
+
+// switchChannel will move the player to the given voice channel if it's
+// connected, restarting the stream if the channel's bitrate differs.
+func (p *Player) switchChannel(pb *playback, ch string) error {
+	if p.voice == nil {
+		return nil
+	}
+	if err := p.voice.ChangeChannel(ch, false, true); err != nil {
+		return fmt.Errorf("change voice channel: %w", err)
+	}
+	p.update(func(s *State) { s.Channel = ch })
+	if err := p.voice.Speaking(pb != nil); err != nil {
+		return fmt.Errorf("set speaking: %w", err)
+	}
+	old := p.Bitrate()
+	p.updateBitrate(ch)
+	if p.Bitrate() != old {
+		p.reloadStream(pb)
+	}
+	return nil
+}
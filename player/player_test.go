@@ -0,0 +1,294 @@
+package player
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	sc "github.com/Depado/soundcloud"
+	"github.com/rs/zerolog"
+	"go.uber.org/fx/fxtest"
+
+	"github.com/depado/fox/cmd"
+	"github.com/depado/fox/models"
+	"github.com/depado/fox/storage"
+	"github.com/depado/fox/tracks"
+)
+
+const testGuild = "guild"
+
+// newTestPlayer returns a player backed by a temporary database, without any
+// Discord session. It's killed at the end of the test.
+func newTestPlayer(t *testing.T) *Player {
+	t.Helper()
+
+	lc := fxtest.NewLifecycle(t)
+	conf := &cmd.Conf{
+		Bot:      cmd.BotConf{Prefix: "fox", LeaveTimeout: time.Minute, Retries: 1},
+		Database: cmd.DatabaseConf{Path: filepath.Join(t.TempDir(), "fox.db")},
+	}
+	bs, err := storage.NewBoltStorage(lc, conf, zerolog.Nop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	lc.RequireStart()
+	t.Cleanup(lc.RequireStop)
+
+	gc, err := bs.NewGuildConf(testGuild)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := NewPlayer(nil, conf, zerolog.Nop(), testGuild, bs, nil, NewEvents(), gc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(p.Kill)
+	return p
+}
+
+func testTrack(id int, user string) tracks.Track {
+	return tracks.SoundcloudTrack{
+		Track:  sc.Track{ID: id, Title: fmt.Sprintf("Track %d", id), Duration: 60000},
+		User:   user,
+		UserID: user,
+	}
+}
+
+// syncPlayer waits for the control goroutine to handle the commands sent so
+// far
+func syncPlayer(t *testing.T, p *Player) {
+	t.Helper()
+	if err := p.Seek(0); !errors.Is(err, ErrNotPlaying) {
+		t.Fatalf("Seek() = %v, want %v", err, ErrNotPlaying)
+	}
+}
+
+// TestPlayerConcurrentCommands sends control and queue commands from several
+// goroutines at once, it's meant to be run with the race detector.
+func TestPlayerConcurrentCommands(t *testing.T) {
+	p := newTestPlayer(t)
+
+	evs, unsubscribe := p.Subscribe()
+	drained := make(chan struct{})
+	go func() {
+		defer close(drained)
+		for range evs {
+		}
+	}()
+
+	const workers, rounds = 8, 40
+	var added, removed atomic.Int64
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			user := fmt.Sprintf("user%d", w%3)
+			for i := 0; i < rounds; i++ {
+				id := w*rounds + i
+				switch i % 12 {
+				case 0:
+					if err := p.SetVolume((id * 7) % 513); err != nil {
+						t.Error(err)
+					}
+					p.Queue.Append(testTrack(id, user))
+					added.Add(1)
+				case 1:
+					p.SetLoop(LoopMode(i % 3))
+					p.Queue.Prepend(testTrack(id, user))
+					added.Add(1)
+				case 2:
+					p.Pause()
+					p.Resume()
+					a := p.Queue.AppendWithin(p.GuildConf().Limits, testTrack(id, user), testTrack(id+1000, user))
+					added.Add(int64(a.Added))
+				case 3:
+					p.Skip()
+					if _, err := p.Queue.Move(1, 3); err != nil && !errors.Is(err, ErrInvalidPosition) {
+						t.Error(err)
+					}
+				case 4:
+					if err := p.Seek(time.Second); !errors.Is(err, ErrNotPlaying) {
+						t.Errorf("Seek() = %v, want %v", err, ErrNotPlaying)
+					}
+					p.Queue.Shuffle()
+				case 5:
+					p.UpdateListeners()
+					if n, err := p.Queue.RemoveRange(1, 2); err == nil {
+						removed.Add(int64(n))
+					}
+				case 6:
+					if err := p.SetAutoplay(i%2 == 0); err != nil {
+						t.Error(err)
+					}
+					_ = p.Queue.GenerateQueueEmbed(1)
+				case 7:
+					if err := p.SetFilters(Filters[w%len(Filters)].Name); err != nil {
+						t.Error(err)
+					}
+					_ = p.Queue.Get()
+				case 8:
+					if _, _, err := p.VoteSkip(user); !errors.Is(err, ErrNotPlaying) {
+						t.Errorf("VoteSkip() = %v, want %v", err, ErrNotPlaying)
+					}
+					removed.Add(int64(p.Queue.RemoveUser(user)))
+				case 9:
+					p.SleepIn(time.Hour)
+					p.CancelSleep()
+				case 10:
+					if err := p.SetVoiceChannel(fmt.Sprintf("voice%d", w)); err != nil {
+						t.Error(err)
+					}
+					_ = p.Snapshot()
+				case 11:
+					p.Stop()
+					if err := p.SetPanel(false); err != nil {
+						t.Error(err)
+					}
+					_ = p.Queue.Pages()
+					_ = p.GuildConf().Filters
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	syncPlayer(t, p)
+
+	if got, want := p.Queue.Len(), int(added.Load()-removed.Load()); got != want {
+		t.Errorf("queue has %d tracks, want %d", got, want)
+	}
+	if v := p.Volume(); v < 0 || v > 512 {
+		t.Errorf("volume is %d, out of range", v)
+	}
+	st := p.Snapshot()
+	if st.Playing || st.Paused || st.AutoPaused {
+		t.Errorf("unexpected state %+v", st)
+	}
+
+	// The player's conf must be the last one saved
+	stored, gc := mustConf(t, p), p.GuildConf()
+	if stored.Autoplay != gc.Autoplay || !slices.Equal(stored.Filters, gc.Filters) {
+		t.Errorf("player conf %+v differs from the stored conf %+v", gc, stored)
+	}
+
+	unsubscribe()
+	<-drained
+}
+
+// TestPlayerConfEdits checks that concurrent edits of the guild conf are all
+// kept.
+func TestPlayerConfEdits(t *testing.T) {
+	p := newTestPlayer(t)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		if err := p.SetAutoplay(true); err != nil {
+			t.Error(err)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		if err := p.SetFilters("bassboost"); err != nil {
+			t.Error(err)
+		}
+	}()
+	wg.Wait()
+	syncPlayer(t, p)
+
+	for name, gc := range map[string]*models.Conf{"player": p.GuildConf(), "stored": mustConf(t, p)} {
+		if !gc.Autoplay || !slices.Equal(gc.Filters, []string{"bassboost"}) {
+			t.Errorf("%s conf lost an edit: autoplay %v, filters %v", name, gc.Autoplay, gc.Filters)
+		}
+	}
+}
+
+func mustConf(t *testing.T, p *Player) *models.Conf {
+	t.Helper()
+	gc, err := p.Storage.GetGuildConf(testGuild)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return gc
+}
+
+// TestPlayerKilled checks that commands sent to a killed player never block.
+func TestPlayerKilled(t *testing.T) {
+	p := newTestPlayer(t)
+	p.Queue.Append(testTrack(1, "user"))
+	p.Kill()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		p.Pause()
+		p.Skip()
+		p.UpdateListeners()
+		if err := p.Seek(time.Second); !errors.Is(err, ErrKilled) {
+			t.Errorf("Seek() = %v, want %v", err, ErrKilled)
+		}
+		if err := p.SetVolume(128); !errors.Is(err, ErrKilled) {
+			t.Errorf("SetVolume() = %v, want %v", err, ErrKilled)
+		}
+		if err := p.SetVoiceChannel("voice"); !errors.Is(err, ErrKilled) {
+			t.Errorf("SetVoiceChannel() = %v, want %v", err, ErrKilled)
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("commands sent to a killed player blocked")
+	}
+}
+
+// TestFairLayoutRequesters checks that requesters are told apart by their ID
+// even when they share a name.
+func TestFairLayoutRequesters(t *testing.T) {
+	track := func(id int, userID string) tracks.Track {
+		return tracks.SoundcloudTrack{Track: sc.Track{ID: id}, User: "fox", UserID: userID}
+	}
+	got := fairLayout(tracks.Tracks{track(1, "a"), track(2, "a"), track(3, "b")})
+	var ids []string
+	for _, tr := range got {
+		ids = append(ids, tr.ID())
+	}
+	if want := []string{"soundcloud:1", "soundcloud:3", "soundcloud:2"}; !slices.Equal(ids, want) {
+		t.Errorf("fairLayout() = %v, want %v", ids, want)
+	}
+}
+
+// TestFairPlacement checks that explicit placements keep the rounds of the
+// fair mode.
+func TestFairPlacement(t *testing.T) {
+	p := newTestPlayer(t)
+	if err := p.EditConf(func(gc *models.Conf) bool {
+		gc.FairQueue = true
+		return true
+	}); err != nil {
+		t.Fatal(err)
+	}
+	syncPlayer(t, p)
+
+	p.Queue.Append(testTrack(1, "a"), testTrack(2, "a"), testTrack(3, "b"), testTrack(4, "b"))
+	// The queue is a1 b3 a2 b4, a2 moves to the front
+	if _, err := p.Queue.Move(3, 1); err != nil {
+		t.Fatal(err)
+	}
+	p.Queue.Insert(0, testTrack(5, "c"))
+	p.Queue.Append(testTrack(6, "c"))
+
+	var ids []string
+	for i := 0; i < p.Queue.Len(); i++ {
+		ids = append(ids, p.Queue.Peek(i).ID())
+	}
+	want := []string{"soundcloud:5", "soundcloud:2", "soundcloud:3", "soundcloud:1", "soundcloud:4", "soundcloud:6"}
+	if !slices.Equal(ids, want) {
+		t.Errorf("queue = %v, want %v", ids, want)
+	}
+}
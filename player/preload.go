@@ -0,0 +1,155 @@
+package player
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/depado/fox/models"
+	"github.com/depado/fox/tracks"
+)
+
+// preloadAhead is how long before the end of the current track the next one
+// starts being prepared.
+const preloadAhead = 15 * time.Second
+
+// preload is an encoding session prepared ahead of time for the next track so
+// that it can start as soon as the current one ends.
+type preload struct {
+	id     string
+	url    string
+	encode *encoder
+	first  []byte
+	// loudness is the measurement of the track used for normalization
+	loudness *models.Loudness
+	// fade is the duration of the previous track's tail mixed in the head of
+	// this session, zero for a gapless transition.
+	fade time.Duration
+}
+
+func (pl *preload) discard() {
+	pl.encode.Cleanup()
+}
+
+// escapeFilterValue escapes a value so that it can be used as an option value
+// inside an ffmpeg filtergraph description.
+func escapeFilterValue(v string) string {
+	v = strings.NewReplacer(`\`, `\\`, `'`, `\'`, `:`, `\:`).Replace(v)
+	return strings.NewReplacer(`\`, `\\`, `'`, `\'`, `[`, `\[`, `]`, `\]`, `,`, `\,`, `;`, `\;`).Replace(v)
+}
+
+// crossfadeFilter returns the ffmpeg filtergraph mixing the tail of the track
+// found at url, starting at start, into the head of the encoded input.
+// The given filter chain, if any, is applied to the mixed output.
+func crossfadeFilter(url string, start, d time.Duration, chain string) string {
+	f := fmt.Sprintf(
+		"amovie=%s:seek_point=%.3f [prev]; [prev][in] acrossfade=d=%.3f",
+		escapeFilterValue(url), start.Seconds(), d.Seconds(),
+	)
+	if chain != "" {
+		f += "," + chain
+	}
+	return f + " [out]"
+}
+
+// crossfade returns the crossfade duration configured for the guild.
+func (p *Player) crossfade() time.Duration {
+	return time.Duration(p.GuildConf().Crossfade) * time.Second
+}
+
+// upcoming returns the track that will be played once the current one ends
+// according to the loop mode, or nil if there is none.
+func (p *Player) upcoming() tracks.Track {
+	switch {
+	case p.lastTrack():
+		return nil
+	case p.Loop() == LoopTrack:
+		return p.Queue.Get()
+	case p.Queue.Len() > 1:
+		return p.Queue.Peek(1)
+	case p.Loop() == LoopQueue:
+		return p.Queue.Get()
+	}
+	return nil
+}
+
+// prepare will resolve the stream of the next track and start its encoding
+// session, mixing in the tail of the current track if a crossfade is
+// configured. The stream URL of the current track is resolved again if it's
+// older than streamTTL. The result is sent on out unless quit is closed first.
+func (p *Player) prepare(cur tracks.Track, curURL string, resolved time.Time, next tracks.Track, out chan<- *preload, quit <-chan struct{}) {
+	curURL, _ = p.resolveAgain(cur, curURL, resolved)
+	pl, err := p.preload(cur, curURL, next)
+	if err != nil {
+		p.log.Err(err).Msg("unable to preload next track")
+	}
+	select {
+	case out <- pl:
+	case <-quit:
+		if pl != nil {
+			pl.discard()
+		}
+	}
+}
+
+func (p *Player) preload(cur tracks.Track, curURL string, next tracks.Track) (*preload, error) {
+	url, err := next.StreamURL()
+	if err != nil {
+		return nil, fmt.Errorf("find a suitable stream: %w", err)
+	}
+
+	pl := &preload{id: next.ID(), url: url}
+	if p.GuildConf().Loudness != 0 {
+		// Waiting for a measurement would delay the transition, the track is
+		// normalized dynamically like in Read instead
+		if pl.loudness = p.loudness(pl.id); pl.loudness == nil {
+			p.measure(pl.id, url)
+		}
+	}
+
+	opts := p.encodeOptions(pl.loudness)
+	chain := opts.AudioFilter
+	curDur := time.Duration(cur.Duration()) * time.Millisecond
+	nextDur := time.Duration(next.Duration()) * time.Millisecond
+	if x := p.crossfade(); x > 0 && curDur > 2*x && nextDur > 2*x {
+		pl.fade = x
+		opts.AudioFilter = crossfadeFilter(curURL, curDur-x, x, opts.AudioFilter)
+	}
+
+	encode, src, err := p.newSession(url, 0, opts, true)
+	if err != nil && pl.fade > 0 {
+		// The transition is gapless rather than not prepared at all
+		p.log.Err(err).Msg("unable to crossfade, preloading without it")
+		pl.fade, opts.AudioFilter = 0, chain
+		encode, src, err = p.newSession(url, 0, opts, true)
+	}
+	if err != nil {
+		return nil, err
+	}
+	pl.encode = encode
+	pl.first = src.(*primedReader).first
+	return pl, nil
+}
+
+// takePreload returns the preloaded session if it matches the given track.
+// Any other preloaded session is discarded.
+func (p *Player) takePreload(t tracks.Track) *preload {
+	pl := p.preloaded
+	p.preloaded = nil
+	if pl == nil {
+		return nil
+	}
+	if pl.id != t.ID() {
+		pl.discard()
+		return nil
+	}
+	return pl
+}
+
+// discardPreload will discard the preloaded session if any.
+func (p *Player) discardPreload() {
+	if p.preloaded != nil {
+		p.preloaded.discard()
+		p.preloaded = nil
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"math/rand"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -18,10 +19,18 @@ type Queue struct {
 
 	tracks tracks.Tracks
 	state  *State
+	// changed is called once the queue was modified, outside of the lock
+	changed func()
 }
 
-func NewQueue(s *State) *Queue {
-	return &Queue{state: s}
+func NewQueue(s *State, changed func()) *Queue {
+	return &Queue{state: s, changed: changed}
+}
+
+func (q *Queue) notify() {
+	if q.changed != nil {
+		q.changed()
+	}
 }
 
 // Duration will return the total duration of the active queue.
@@ -52,11 +61,24 @@ func (q *Queue) Len() int {
 
 // Prepend will add tracks to the start of the queue, either right after the
 // currently playing track, or right at the start if the player is stopped.
+// In fair mode, the requester takes the first turn and their other tracks are
+// pushed back by as many rounds as tracks were added.
 func (q *Queue) Prepend(t ...tracks.Track) {
+	defer q.notify()
 	q.Lock()
 	defer q.Unlock()
 
-	if q.state.Playing && len(q.tracks) != 0 {
+	q.prependTracks(t...)
+}
+
+// prependTracks adds tracks to the start of the queue, the lock must be held
+func (q *Queue) prependTracks(t ...tracks.Track) {
+	if q.state.fair() {
+		i := q.upcoming()
+		q.tracks = append(q.tracks[:i:i], fairLayout(append(append(tracks.Tracks{}, t...), q.tracks[i:]...))...)
+		return
+	}
+	if q.state.playing() && len(q.tracks) != 0 {
 		tr := append(tracks.Tracks{q.tracks[0]}, t...)
 		q.tracks = append(tr, q.tracks[1:]...)
 	} else {
@@ -64,16 +86,45 @@ func (q *Queue) Prepend(t ...tracks.Track) {
 	}
 }
 
-// Append will append tracks at the end of queue.
+// Insert will insert tracks at the given position in queue, or at the end if
+// the position is beyond it. In fair mode, the rounds are then laid out again.
+func (q *Queue) Insert(i int, t ...tracks.Track) {
+	defer q.notify()
+	q.Lock()
+	defer q.Unlock()
+
+	i = min(max(i, 0), len(q.tracks))
+	q.tracks = append(q.tracks[:i], append(append(tracks.Tracks{}, t...), q.tracks[i:]...)...)
+	q.relayout()
+}
+
+// Append will append tracks at the end of queue. In fair mode, each track is
+// added to the first round in which its requester has no track yet.
 func (q *Queue) Append(t ...tracks.Track) {
+	defer q.notify()
 	q.Lock()
 	defer q.Unlock()
 
+	q.appendTracks(t...)
+}
+
+// appendTracks adds tracks to the end of the queue, the lock must be held
+func (q *Queue) appendTracks(t ...tracks.Track) {
+	if q.state.fair() {
+		i := q.upcoming()
+		up := q.tracks[i:]
+		for _, tr := range t {
+			up = fairInsert(up, tr)
+		}
+		q.tracks = append(q.tracks[:i:i], up...)
+		return
+	}
 	q.tracks = append(q.tracks, t...)
 }
 
 // Pop will remove the first track in queue.
 func (q *Queue) Pop() {
+	defer q.notify()
 	q.Lock()
 	defer q.Unlock()
 
@@ -86,6 +137,7 @@ func (q *Queue) Pop() {
 // one track in queue. Otherwise it does nothing, leaving the first track in its
 // position to be played once more.
 func (q *Queue) Loop() {
+	defer q.notify()
 	q.Lock()
 	defer q.Unlock()
 
@@ -96,6 +148,17 @@ func (q *Queue) Loop() {
 	}
 }
 
+// Swap will swap the tracks at the given positions in queue if they exist.
+func (q *Queue) Swap(i, j int) {
+	defer q.notify()
+	q.Lock()
+	defer q.Unlock()
+
+	if i >= 0 && j >= 0 && i < len(q.tracks) && j < len(q.tracks) {
+		q.tracks[i], q.tracks[j] = q.tracks[j], q.tracks[i]
+	}
+}
+
 // Get will return the first track in queue if any. If there is no track in
 // queue, nil will be returned.
 func (q *Queue) Get() tracks.Track {
@@ -108,9 +171,21 @@ func (q *Queue) Get() tracks.Track {
 	return nil
 }
 
+// Peek will return the track at the given position in queue if any.
+func (q *Queue) Peek(i int) tracks.Track {
+	q.Lock()
+	defer q.Unlock()
+
+	if i >= 0 && i < len(q.tracks) {
+		return q.tracks[i]
+	}
+	return nil
+}
+
 // Shuffle will shuffle all the tracks in queue, except the first one if it's
 // currently being played.
 func (q *Queue) Shuffle() {
+	defer q.notify()
 	q.Lock()
 	defer q.Unlock()
 
@@ -120,7 +195,7 @@ func (q *Queue) Shuffle() {
 	}
 
 	// If the first track is currently being played, do not shuffle it
-	if q.state.Playing {
+	if q.state.playing() {
 		t := q.tracks[0]
 		ts := q.tracks[1:]
 		rand.Shuffle(len(ts), func(i, j int) { ts[i], ts[j] = ts[j], ts[i] })
@@ -133,13 +208,19 @@ func (q *Queue) Shuffle() {
 // Clear will reset the queue, removing all tracks from it except the first one
 // if it is currently played.
 func (q *Queue) Clear() {
+	defer q.notify()
 	q.Lock()
 	defer q.Unlock()
 
+	q.clear()
+}
+
+// clear resets the queue, the lock must be held
+func (q *Queue) clear() {
 	if len(q.tracks) == 0 {
 		return
 	}
-	if q.state.Playing {
+	if q.state.playing() {
 		q.tracks = tracks.Tracks{q.tracks[0]}
 	} else {
 		q.tracks = tracks.Tracks{}
@@ -148,38 +229,151 @@ func (q *Queue) Clear() {
 
 // RemoveN will remove the next n tracks in queue
 func (q *Queue) RemoveN(n int) {
+	defer q.notify()
 	q.Lock()
 	defer q.Unlock()
 
-	if n >= len(q.tracks) || (q.state.Playing && n+1 >= len(q.tracks)) {
-		q.Clear()
+	if n >= len(q.tracks) || (q.state.playing() && n+1 >= len(q.tracks)) {
+		q.clear()
 		return
 	}
-	if q.state.Playing {
+	if q.state.playing() {
 		q.tracks = append(tracks.Tracks{q.tracks[0]}, q.tracks[n+1:]...)
 	} else {
 		q.tracks = q.tracks[n:]
 	}
 }
 
-func (q *Queue) GenerateQueueEmbed() *discordgo.MessageEmbed {
+// The following operations address the upcoming tracks by their position in
+// queue, starting at 1. The track being played is never touched.
+
+// index returns the index of the upcoming track at the given position, and
+// whether there is such a track. The lock must be held.
+func (q *Queue) index(pos int) (int, bool) {
+	i := q.upcoming() + pos - 1
+	return i, pos >= 1 && i < len(q.tracks)
+}
+
+// Move will move the upcoming track at the given position to another one,
+// and return it. In fair mode, the rounds are then laid out again.
+func (q *Queue) Move(from, to int) (tracks.Track, error) {
+	defer q.notify()
 	q.Lock()
 	defer q.Unlock()
 
+	i, ok := q.index(from)
+	j, ok2 := q.index(to)
+	if !ok || !ok2 {
+		return nil, ErrInvalidPosition
+	}
+	t := q.tracks[i]
+	q.tracks = append(q.tracks[:i], q.tracks[i+1:]...)
+	q.tracks = append(q.tracks[:j], append(tracks.Tracks{t}, q.tracks[j:]...)...)
+	q.relayout()
+	return t, nil
+}
+
+// RemoveRange will remove the upcoming tracks between the given positions,
+// both included, and return how many were removed. The end of the range is
+// capped to the end of the queue.
+func (q *Queue) RemoveRange(from, to int) (int, error) {
+	defer q.notify()
+	q.Lock()
+	defer q.Unlock()
+
+	i, ok := q.index(from)
+	if !ok || to < from {
+		return 0, ErrInvalidPosition
+	}
+	j := min(q.upcoming()+to, len(q.tracks))
+	q.tracks = append(q.tracks[:i], q.tracks[j:]...)
+	return j - i, nil
+}
+
+// RemoveUser will remove all the upcoming tracks added by the user with the
+// given ID, and return how many were removed.
+func (q *Queue) RemoveUser(user string) int {
+	defer q.notify()
+	q.Lock()
+	defer q.Unlock()
+
+	i := q.upcoming()
+	kept := append(tracks.Tracks{}, q.tracks[:i]...)
+	for _, t := range q.tracks[i:] {
+		if requester(t) != user {
+			kept = append(kept, t)
+		}
+	}
+	n := len(q.tracks) - len(kept)
+	q.tracks = kept
+	return n
+}
+
+// insertAt adds tracks so that the first one ends up at the given position,
+// or at the end of the queue if the position is beyond it. In fair mode, the
+// rounds are then laid out again. The lock must be held.
+func (q *Queue) insertAt(pos int, t ...tracks.Track) {
+	i := min(q.upcoming()+max(pos, 1)-1, len(q.tracks))
+	q.tracks = append(q.tracks[:i], append(append(tracks.Tracks{}, t...), q.tracks[i:]...)...)
+	q.relayout()
+}
+
+// QueuePageSize is the number of tracks displayed on a single page
+const QueuePageSize = 10
+
+// Pages returns the number of pages needed to display the whole queue.
+func (q *Queue) Pages() int {
+	n := q.Len()
+	if n == 0 {
+		return 1
+	}
+	return (n + QueuePageSize - 1) / QueuePageSize
+}
+
+// GenerateQueueEmbed will generate the embed displaying the given page of the
+// queue, starting at 1. Each track shows its position, duration, requester
+// and the estimated time before it plays.
+func (q *Queue) GenerateQueueEmbed(page int) *discordgo.MessageEmbed {
+	q.Lock()
+	defer q.Unlock()
+
+	pages := max((len(q.tracks)+QueuePageSize-1)/QueuePageSize, 1)
+	page = min(max(page, 1), pages)
+
 	var body string
 	var tot int
-	if len(q.tracks) > 0 {
-		for i, t := range q.tracks {
-			if i <= 10 {
-				body += t.MarkdownLink()
+	for _, t := range q.tracks {
+		tot += t.Duration()
+	}
+	if len(q.tracks) == 0 {
+		body = "There is currently no track in queue"
+	}
+
+	up := q.upcoming()
+	var eta time.Duration
+	if up > 0 {
+		eta = time.Duration(q.tracks[0].Duration())*time.Millisecond - q.state.position()
+	}
+	start := (page - 1) * QueuePageSize
+	for i, t := range q.tracks {
+		if i >= start+QueuePageSize {
+			break
+		}
+		d := time.Duration(t.Duration()) * time.Millisecond
+		if i >= start {
+			u, _ := t.GetUser()
+			link := strings.TrimSuffix(t.MarkdownLink(), "\n")
+			if i < up {
+				body += fmt.Sprintf("▶️ %s\n`%s` • Added by %s • Playing now\n", link, fmtDuration(d), u)
+			} else if eta == 0 {
+				body += fmt.Sprintf("`%d.` %s\n`%s` • Added by %s • Plays first\n", i-up+1, link, fmtDuration(d), u)
+			} else {
+				body += fmt.Sprintf("`%d.` %s\n`%s` • Added by %s • Plays in %s\n", i-up+1, link, fmtDuration(d), u, fmtDuration(eta))
 			}
-			tot += t.Duration()
 		}
-		if len(q.tracks) > 10 {
-			body += fmt.Sprintf("\nAnd **%d** other tracks", len(q.tracks)-10)
+		if i >= up {
+			eta += d
 		}
-	} else {
-		body = "There is currently no track in queue"
 	}
 
 	e := &discordgo.MessageEmbed{
@@ -190,6 +384,18 @@ func (q *Queue) GenerateQueueEmbed() *discordgo.MessageEmbed {
 			{Name: "Tracks", Value: strconv.Itoa(len(q.tracks)), Inline: true},
 			{Name: "Duration", Value: durafmt.Parse(time.Duration(tot) * time.Millisecond).LimitFirstN(2).String(), Inline: true},
 		},
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: fmt.Sprintf("Page %d/%d", page, pages),
+		},
+	}
+	if q.state.fair() {
+		if turns := q.turns(); len(turns) > 0 {
+			e.Fields = append(e.Fields, &discordgo.MessageEmbedField{
+				Name:   "⚖️ Next turns",
+				Value:  strings.Join(turns, " → "),
+				Inline: false,
+			})
+		}
 	}
 
 	return e
@@ -0,0 +1,84 @@
+package player
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/depado/fox/tracks"
+)
+
+// endTolerance is how far from the end of the track a stream can end without
+// being considered as failed
+const endTolerance = 10 * time.Second
+
+// maxBackoff is the maximum time to wait between two attempts
+const maxBackoff = 16 * time.Second
+
+var (
+	// ErrPrematureEnd is returned when the stream ended long before the end of
+	// the track
+	ErrPrematureEnd = errors.New("stream ended before the end of the track")
+	// errNoStream is returned when no stream could be resolved for a track
+	errNoStream = errors.New("no suitable stream")
+)
+
+// backoff returns the time to wait before the given attempt
+func backoff(attempt int) time.Duration {
+	d := time.Second << (attempt - 1)
+	if d > maxBackoff || d <= 0 {
+		return maxBackoff
+	}
+	return d
+}
+
+// playTrack will play the given track. If the stream can't be resolved or
+// fails mid-track, the stream URL is resolved again and playback resumes at
+// the last known position, waiting longer after each attempt. Once the retry
+// budget is spent, a notice is sent to explain why the track is skipped.
+func (p *Player) playTrack(t tracks.Track) error {
+	var err error
+	pl := p.takePreload(t)
+	p.announced = false
+
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 {
+			if attempt > p.conf.Bot.Retries {
+				break
+			}
+			d := backoff(attempt)
+			p.log.Warn().Err(err).Int("attempt", attempt).Dur("backoff", d).Msg("retrying track")
+			// The player can still be stopped or skip the track meanwhile
+			if !p.wait(d) {
+				p.startAt = 0
+				return nil
+			}
+		}
+
+		url := ""
+		if pl != nil {
+			url = pl.url
+		} else if url, err = t.StreamURL(); err != nil {
+			err = fmt.Errorf("%w: %w", errNoStream, err)
+			continue
+		}
+
+		if err = p.Read(t, url, pl); err == nil || p.Stopped() {
+			return nil
+		}
+		pl = nil
+	}
+
+	p.startAt = 0
+	reason := "the stream kept failing"
+	if errors.Is(err, errNoStream) {
+		reason = "SoundCloud didn't provide any playable stream"
+	}
+	p.emit(Event{Type: TrackFailed, Track: t, Err: err})
+	p.SendNotice(
+		"⚠️ Skipped a track",
+		fmt.Sprintf("I couldn't play %s\nI gave up after %d attempts because %s.", t.MarkdownLink(), p.conf.Bot.Retries+1, reason),
+		"",
+	)
+	return err
+}
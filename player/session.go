@@ -0,0 +1,86 @@
+package player
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/depado/fox/models"
+	"github.com/depado/fox/tracks"
+)
+
+// autosaveInterval is the interval at which the players' sessions are saved
+const autosaveInterval = time.Minute
+
+// Session returns a snapshot of the player's state that can be saved and
+// restored later.
+func (p *Player) Session() *models.Session {
+	p.Queue.RLock()
+	saved := make([]models.SavedTrack, len(p.Queue.tracks))
+	for i, t := range p.Queue.tracks {
+		saved[i] = t.Save()
+	}
+	p.Queue.RUnlock()
+
+	st := p.Snapshot()
+	s := &models.Session{
+		Tracks:  saved,
+		Volume:  st.Volume,
+		Loop:    int(st.Loop),
+		Playing: st.Playing && !st.Paused,
+		SavedAt: time.Now(),
+	}
+	if st.Playing {
+		s.Position = st.Position
+	}
+	return s
+}
+
+// Save will save the player's session in the guild bucket.
+func (p *Player) Save() error {
+	if err := p.Storage.SaveSession(p.Guild, p.Session()); err != nil {
+		return fmt.Errorf("save session: %w", err)
+	}
+	return nil
+}
+
+// Restore will restore a saved session, filling the queue with the given
+// tracks. If resume is true and the player was playing when the session was
+// saved, playback starts again where it left off.
+func (p *Player) Restore(s *models.Session, tr tracks.Tracks, resume bool) {
+	p.Queue.Append(tr...)
+	if s.Volume >= 0 && s.Volume <= 512 {
+		p.send(volumeCmd{volume: s.Volume})
+	}
+	p.send(loopCmd{mode: LoopMode(s.Loop)})
+
+	if resume && s.Playing && len(tr) > 0 {
+		p.send(playCmd{at: s.Position})
+	}
+}
+
+// Save will save the session of all the players.
+func (p *Players) Save() {
+	p.RLock()
+	defer p.RUnlock()
+
+	for _, pl := range p.Players {
+		if err := pl.Save(); err != nil {
+			pl.log.Err(err).Msg("unable to save session")
+		}
+	}
+}
+
+// autosave will periodically save the players' sessions until quit is closed
+func (p *Players) autosave(quit <-chan struct{}) {
+	tc := time.NewTicker(autosaveInterval)
+	defer tc.Stop()
+
+	for {
+		select {
+		case <-tc.C:
+			p.Save()
+		case <-quit:
+			return
+		}
+	}
+}
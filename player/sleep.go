@@ -0,0 +1,182 @@
+package player
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// sleepFade is how long the volume fades out before the player stops when
+// going to sleep
+const sleepFade = 8 * time.Second
+
+// SleepMode defines when the player will stop by itself.
+type SleepMode int
+
+const (
+	// SleepOff never stops the player
+	SleepOff SleepMode = iota
+	// SleepTimer stops the player at a given time
+	SleepTimer
+	// SleepTracks stops the player after a given number of tracks
+	SleepTracks
+)
+
+// Sleep describes when the player will stop by itself.
+type Sleep struct {
+	Mode SleepMode
+	// At is when the player stops in timer mode
+	At time.Time
+	// Tracks is the number of tracks left to play in tracks mode, including
+	// the current one
+	Tracks int
+}
+
+// String returns a user-friendly representation of the sleep timer
+func (s Sleep) String() string {
+	switch s.Mode {
+	case SleepTimer:
+		return fmt.Sprintf("Stopping <t:%d:R>", s.At.Unix())
+	case SleepTracks:
+		if s.Tracks <= 1 {
+			return "Stopping after this track"
+		}
+		return fmt.Sprintf("Stopping after %d more tracks", s.Tracks-1)
+	default:
+		return "Off"
+	}
+}
+
+// sleeper holds the sleep timer of a player
+type sleeper struct {
+	sync.Mutex
+	Sleep
+
+	timer  *time.Timer
+	fading bool
+}
+
+// SleepIn will stop the player after the given duration, fading out first.
+func (p *Player) SleepIn(d time.Duration) {
+	p.CancelSleep()
+
+	p.sleep.Lock()
+	defer p.sleep.Unlock()
+	p.sleep.Sleep = Sleep{Mode: SleepTimer, At: time.Now().Add(d)}
+	p.sleep.timer = time.AfterFunc(max(d-sleepFade, 0), p.fadeOut)
+}
+
+// SleepAfter will stop the player once the given number of tracks have been
+// played, including the current one. The last track fades out.
+func (p *Player) SleepAfter(n int) {
+	p.CancelSleep()
+
+	p.sleep.Lock()
+	defer p.sleep.Unlock()
+	p.sleep.Sleep = Sleep{Mode: SleepTracks, Tracks: n}
+}
+
+// CancelSleep will cancel the sleep timer if any and restore the volume if it
+// was fading out.
+func (p *Player) CancelSleep() {
+	if p.clearSleep() {
+		p.reload()
+	}
+}
+
+// SleepStatus returns when the player will stop by itself.
+func (p *Player) SleepStatus() Sleep {
+	p.sleep.Lock()
+	defer p.sleep.Unlock()
+	return p.sleep.Sleep
+}
+
+// clearSleep resets the sleep timer and returns whether the player was fading
+// out.
+func (p *Player) clearSleep() bool {
+	p.sleep.Lock()
+	defer p.sleep.Unlock()
+
+	if p.sleep.timer != nil {
+		p.sleep.timer.Stop()
+		p.sleep.timer = nil
+	}
+	fading := p.sleep.fading
+	p.sleep.Sleep = Sleep{}
+	p.sleep.fading = false
+	return fading
+}
+
+// fading returns whether the player is fading out before going to sleep
+func (p *Player) fading() bool {
+	p.sleep.Lock()
+	defer p.sleep.Unlock()
+	return p.sleep.fading
+}
+
+// lastTrack returns whether the player goes to sleep once the current track
+// ends
+func (p *Player) lastTrack() bool {
+	s := p.SleepStatus()
+	return s.Mode == SleepTracks && s.Tracks <= 1
+}
+
+// fadeOut will start fading out the current track. In timer mode, the player
+// is stopped once faded out.
+func (p *Player) fadeOut() {
+	p.sleep.Lock()
+	mode := p.sleep.Mode
+	if mode == SleepOff || p.sleep.fading {
+		p.sleep.Unlock()
+		return
+	}
+	if p.Playing() && !p.Paused() {
+		p.sleep.fading = true
+	}
+	if mode == SleepTimer {
+		p.sleep.timer = time.AfterFunc(sleepFade, p.goToSleep)
+	}
+	fading := p.sleep.fading
+	p.sleep.Unlock()
+
+	if fading {
+		p.reload()
+	}
+}
+
+// goToSleep will stop the player once the sleep timer is over.
+func (p *Player) goToSleep() {
+	p.clearSleep()
+	if !p.Playing() {
+		return
+	}
+	p.Stop()
+	p.SendNotice("💤 Good night", "The sleep timer is over, playback stopped", "")
+}
+
+// trackEnded counts a played track in tracks mode and returns whether the
+// player should go to sleep.
+func (p *Player) trackEnded() bool {
+	p.sleep.Lock()
+	defer p.sleep.Unlock()
+
+	if p.sleep.Mode != SleepTracks {
+		return false
+	}
+	p.sleep.Tracks--
+	if p.sleep.Tracks > 0 {
+		return false
+	}
+	p.sleep.Sleep = Sleep{}
+	p.sleep.fading = false
+	return true
+}
+
+// fadeFilter returns the filter fading out the stream from its start, if the
+// player is fading out.
+func (p *Player) fadeFilter() string {
+	if !p.fading() {
+		return ""
+	}
+	return fmt.Sprintf("afade=t=out:st=0:d=%d", int(sleepFade.Seconds()))
+}
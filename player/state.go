@@ -1,18 +1,78 @@
 package player
 
 import (
+	"errors"
 	"fmt"
 	"sync"
+	"time"
+
+	"github.com/depado/fox/models"
+	"github.com/depado/fox/tracks"
+)
+
+var (
+	// ErrNotPlaying is returned when an action requires an active stream
+	ErrNotPlaying = errors.New("player is not playing")
+	// ErrSeekOutOfRange is returned when seeking outside of the track bounds
+	ErrSeekOutOfRange = errors.New("seek position out of range")
+	// ErrInvalidPosition is returned when no upcoming track is found at the
+	// given position in queue
+	ErrInvalidPosition = errors.New("invalid position in queue")
+	// ErrEmptyHistory is returned when no track was played yet
+	ErrEmptyHistory = errors.New("history is empty")
+	// ErrKilled is returned when the player was killed
+	ErrKilled = errors.New("player was killed")
+)
+
+// LoopMode defines what happens to a track once it has been played.
+type LoopMode int
+
+const (
+	// LoopOff removes tracks from the queue once played
+	LoopOff LoopMode = iota
+	// LoopTrack replays the current track indefinitely
+	LoopTrack
+	// LoopQueue moves played tracks to the back of the queue
+	LoopQueue
 )
 
-// State stores the various state of the player. It is also used by the queue
-// to determine some actions related to currently playing tracks.
+// String returns a user-friendly representation of the loop mode
+func (l LoopMode) String() string {
+	switch l {
+	case LoopTrack:
+		return "🔂 Repeat track"
+	case LoopQueue:
+		return "🔁 Repeat queue"
+	default:
+		return "➡️ No repeat"
+	}
+}
+
+// State stores the various state of the player. It is only modified by the
+// control goroutine of the player, and published so that it can be read from
+// anywhere. It is also used by the queue to determine some actions related to
+// currently playing tracks.
 type State struct {
 	sync.RWMutex
 	Playing bool
 	Stopped bool
 	Paused  bool
 	Volume  int
+	Loop    LoopMode
+
+	// Position is the playback position in the current track
+	Position time.Duration
+	// Channel is the voice channel the player is connected to
+	Channel string
+	// Bitrate is the bitrate of the voice channel in kb/s
+	Bitrate int
+
+	// AutoPaused is true when the player was paused because nobody was left
+	// listening in the voice channel
+	AutoPaused bool
+
+	conf  *models.Conf
+	stats *Stats
 }
 
 // NewPlayerState will return a new player state
@@ -22,6 +82,58 @@ func NewState() *State {
 	}
 }
 
+// playing is used by the queue which already holds its own lock
+func (s *State) playing() bool {
+	s.RLock()
+	defer s.RUnlock()
+	return s.Playing
+}
+
+// position is used by the queue which already holds its own lock
+func (s *State) position() time.Duration {
+	s.RLock()
+	defer s.RUnlock()
+	return s.Position
+}
+
+// Snapshot is a copy of the player state at a given time
+type Snapshot struct {
+	Playing    bool
+	Stopped    bool
+	Paused     bool
+	AutoPaused bool
+	Volume     int
+	Loop       LoopMode
+	Position   time.Duration
+	Channel    string
+	Bitrate    int
+}
+
+// Snapshot returns a consistent copy of the player state
+func (p *Player) Snapshot() Snapshot {
+	p.state.RLock()
+	defer p.state.RUnlock()
+	return Snapshot{
+		Playing:    p.state.Playing,
+		Stopped:    p.state.Stopped,
+		Paused:     p.state.Paused,
+		AutoPaused: p.state.AutoPaused,
+		Volume:     p.state.Volume,
+		Loop:       p.state.Loop,
+		Position:   p.state.Position,
+		Channel:    p.state.Channel,
+		Bitrate:    p.state.Bitrate,
+	}
+}
+
+// update will modify the state. It must only be called from the control
+// goroutine.
+func (p *Player) update(f func(s *State)) {
+	p.state.Lock()
+	defer p.state.Unlock()
+	f(p.state)
+}
+
 func (p *Player) Playing() bool {
 	p.state.RLock()
 	defer p.state.RUnlock()
@@ -40,76 +152,113 @@ func (p *Player) Paused() bool {
 	return p.state.Paused
 }
 
+func (p *Player) Loop() LoopMode {
+	p.state.RLock()
+	defer p.state.RUnlock()
+	return p.state.Loop
+}
+
 func (p *Player) Volume() int {
 	p.state.RLock()
 	defer p.state.RUnlock()
 	return p.state.Volume
 }
 
+// Position returns the current playback position in the playing track.
+func (p *Player) Position() time.Duration {
+	p.state.RLock()
+	defer p.state.RUnlock()
+	return p.state.Position
+}
+
+// GuildConf returns the conf of the guild the player belongs to. It must not
+// be modified, use UpdateConf instead.
+func (p *Player) GuildConf() *models.Conf {
+	p.state.RLock()
+	defer p.state.RUnlock()
+	return p.state.conf
+}
+
+// Stats returns the stats of the current encoding session if any
+func (p *Player) Stats() *Stats {
+	p.state.RLock()
+	defer p.state.RUnlock()
+	return p.state.stats
+}
+
+// SetLoop will set the loop mode of the player
+func (p *Player) SetLoop(l LoopMode) {
+	p.send(loopCmd{mode: l})
+}
+
 // Stop will immediately stop the player
 func (p *Player) Stop() {
-	p.state.Lock()
-	defer p.state.Unlock()
-
-	if !p.state.Playing {
-		return
-	}
-	p.state.Stopped = true
-	p.stop <- true
+	p.send(stopCmd{})
 }
 
 // Pause will pause an ongoing stream
 func (p *Player) Pause() {
-	p.state.Lock()
-	defer p.state.Unlock()
+	p.send(pauseCmd{})
+}
 
-	if p.stream == nil || !p.state.Playing {
-		return
-	}
-	p.state.Paused = true
-	p.stream.SetPaused(true)
+// Resume will resume a paused stream
+func (p *Player) Resume() {
+	p.send(resumeCmd{})
 }
 
 // Skip will skip the currently playing track
 func (p *Player) Skip() {
-	p.state.Lock()
-	defer p.state.Unlock()
+	p.send(skipCmd{})
+}
 
-	if !p.state.Playing {
-		return
+// Previous will play the last played track again, removing it from history.
+// The currently playing track, if any, is played right after it.
+func (p *Player) Previous() (tracks.Track, error) {
+	e, ok := p.History.Pop()
+	if !ok {
+		return nil, ErrEmptyHistory
+	}
+	if !p.send(previousCmd{track: e.Track}) {
+		return nil, ErrKilled
 	}
-	p.stop <- true
+	return e.Track, nil
 }
 
-// Resume will resume a paused stream
-func (p *Player) Resume() {
-	p.state.Lock()
-	defer p.state.Unlock()
-
-	if p.stream == nil || !p.state.Paused {
-		return
+// Seek will restart the currently playing track at the given position. The
+// pause state of the player is preserved.
+func (p *Player) Seek(pos time.Duration) error {
+	done := make(chan error, 1)
+	if !p.send(seekCmd{pos: pos, done: done}) {
+		return ErrKilled
+	}
+	select {
+	case err := <-done:
+		return err
+	case <-p.quit:
+		return ErrKilled
 	}
-	p.state.Paused = false
-	p.stream.SetPaused(false)
 }
 
-// SetVolume will set the volume for the next track
-func (p *Player) SetVolume(v int) error {
-	p.state.Lock()
-	defer p.state.Unlock()
+// reload will seamlessly restart the currently playing track at the current
+// position, so that changes to the encoding options are applied to it. It has
+// no effect if the player isn't playing.
+func (p *Player) reload() {
+	p.send(reloadCmd{})
+}
 
+// SetVolume will set the volume and apply it to the currently playing track
+func (p *Player) SetVolume(v int) error {
 	// Invalid values
 	if v < 0 || v > 512 {
 		return fmt.Errorf("invalid volume value")
 	}
-	p.state.Volume = v
+	if !p.send(volumeCmd{volume: v}) {
+		return ErrKilled
+	}
 	return nil
 }
 
 func (p *Player) SetVolumePercent(v int) error {
-	p.state.Lock()
-	defer p.state.Unlock()
-
 	if v < 0 || v > 200 {
 		return fmt.Errorf("invalid volume percentage")
 	}
@@ -0,0 +1,79 @@
+package player
+
+import (
+	"errors"
+	"slices"
+	"sync"
+)
+
+// ErrNotListening is returned when someone who isn't in the voice channel votes
+var ErrNotListening = errors.New("not listening")
+
+// votes holds the users who voted to skip the current track
+type votes struct {
+	sync.Mutex
+	users map[string]bool
+}
+
+// resetVotes will clear the votes, it's called whenever a track starts
+func (p *Player) resetVotes() {
+	p.votes.Lock()
+	defer p.votes.Unlock()
+	p.votes.users = nil
+}
+
+// VoteSkip will register the vote of the user to skip the current track. Only
+// the votes of the users still in the voice channel are counted, and the
+// track is skipped once they reach the share of listeners set in the guild
+// conf. It returns the number of votes and the number of votes needed.
+func (p *Player) VoteSkip(user string) (int, int, error) {
+	if !p.Playing() {
+		return 0, 0, ErrNotPlaying
+	}
+	ls := p.Listeners()
+	if !slices.Contains(ls, user) {
+		return 0, 0, ErrNotListening
+	}
+
+	needed := votesNeeded(len(ls), p.GuildConf().VoteSkip)
+	count := p.vote(user, ls, needed)
+	// Skipping waits for the control goroutine, which resets the votes when
+	// the next track starts, so it's done without holding the votes lock
+	if count >= needed {
+		p.Skip()
+	}
+	return count, needed, nil
+}
+
+// vote will register the vote of the user and return the number of votes of
+// the listeners. The votes are cleared once they reach the needed count.
+func (p *Player) vote(user string, listeners []string, needed int) int {
+	p.votes.Lock()
+	defer p.votes.Unlock()
+
+	if p.votes.users == nil {
+		p.votes.users = make(map[string]bool)
+	}
+	p.votes.users[user] = true
+
+	var count int
+	for _, l := range listeners {
+		if p.votes.users[l] {
+			count++
+		}
+	}
+	if count >= needed {
+		p.votes.users = nil
+	}
+	return count
+}
+
+// votesNeeded returns the number of votes needed to skip a track given the
+// number of listeners and the required share in percent, rounded up.
+func votesNeeded(listeners, share int) int {
+	n := (listeners*share + 99) / 100
+	if n < 1 {
+		n = 1
+	}
+	return n
+}
@@ -0,0 +1,153 @@
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// field is the definition of a cron expression field
+type field struct {
+	name     string
+	min, max int
+}
+
+var fields = []field{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 7},
+}
+
+// macros are the supported shorthands for common expressions
+var macros = map[string]string{
+	"@hourly":  "0 * * * *",
+	"@daily":   "0 0 * * *",
+	"@weekly":  "0 0 * * 0",
+	"@monthly": "0 0 1 * *",
+}
+
+// Cron is a parsed cron expression with the usual five fields: minute, hour,
+// day of month, month and day of week.
+type Cron struct {
+	sets [5]uint64
+	// When both days are restricted, either of them has to match. A day
+	// field starting with a star doesn't count as restricted.
+	anyDom bool
+	anyDow bool
+}
+
+// ParseCron will parse a cron expression. Each field is either `*`, a value,
+// a range (`1-5`), a step (`*/15`, `0-30/10`) or a list of those (`1,3,5`).
+// Days of week go from 0 (Sunday) to 7 (Sunday again).
+func ParseCron(expr string) (*Cron, error) {
+	if m, ok := macros[strings.TrimSpace(expr)]; ok {
+		expr = m
+	}
+	parts := strings.Fields(expr)
+	if len(parts) != len(fields) {
+		return nil, fmt.Errorf("expected %d fields, got %d", len(fields), len(parts))
+	}
+
+	// Like in standard cron, a day field starting with a star such as `*/2`
+	// doesn't restrict the days matched by the other one
+	c := &Cron{anyDom: strings.HasPrefix(parts[2], "*"), anyDow: strings.HasPrefix(parts[4], "*")}
+	for i, p := range parts {
+		set, err := parseField(p, fields[i])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", fields[i].name, err)
+		}
+		c.sets[i] = set
+	}
+	// Sunday can be written either 0 or 7
+	if c.sets[4]&(1<<7) != 0 {
+		c.sets[4] |= 1
+	}
+	return c, nil
+}
+
+func parseField(v string, f field) (uint64, error) {
+	var set uint64
+	for _, item := range strings.Split(v, ",") {
+		step := 1
+		if r, s, ok := strings.Cut(item, "/"); ok {
+			n, err := strconv.Atoi(s)
+			if err != nil || n < 1 {
+				return 0, fmt.Errorf("invalid step %q", s)
+			}
+			item, step = r, n
+		}
+
+		lo, hi := f.min, f.max
+		if item != "*" {
+			var err error
+			a, b, isRange := strings.Cut(item, "-")
+			if lo, err = strconv.Atoi(a); err != nil {
+				return 0, fmt.Errorf("invalid value %q", a)
+			}
+			hi = lo
+			if isRange {
+				if hi, err = strconv.Atoi(b); err != nil {
+					return 0, fmt.Errorf("invalid value %q", b)
+				}
+			} else if step > 1 {
+				hi = f.max
+			}
+		}
+		if lo < f.min || hi > f.max || lo > hi {
+			return 0, fmt.Errorf("%q is out of range %d-%d", item, f.min, f.max)
+		}
+		for i := lo; i <= hi; i += step {
+			set |= 1 << i
+		}
+	}
+	return set, nil
+}
+
+// has returns whether the value is part of the i-th field
+func (c *Cron) has(i, v int) bool {
+	return c.sets[i]&(1<<v) != 0
+}
+
+// Match returns whether the given time matches the expression, to the minute.
+func (c *Cron) Match(t time.Time) bool {
+	return c.has(0, t.Minute()) && c.has(1, t.Hour()) && c.has(3, int(t.Month())) && c.day(t)
+}
+
+// day returns whether the day of the given time matches the expression
+func (c *Cron) day(t time.Time) bool {
+	dom, dow := c.has(2, t.Day()), c.has(4, int(t.Weekday()))
+	if c.anyDom || c.anyDow {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+// nextSearch is how far ahead Next looks for a matching time, enough to find
+// the next 29th of February
+const nextSearch = 5
+
+// Next returns the first time matching the expression after the given time,
+// or the zero time if there is none within five years. Months, days and hours
+// that don't match are skipped as a whole.
+func (c *Cron) Next(after time.Time) time.Time {
+	loc := after.Location()
+	t := after.Truncate(time.Minute).Add(time.Minute)
+	for end := t.AddDate(nextSearch, 0, 0); t.Before(end); {
+		switch {
+		case !c.has(3, int(t.Month())):
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+		case !c.day(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+		case !c.has(1, t.Hour()):
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+		case !c.has(0, t.Minute()):
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
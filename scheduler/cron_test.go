@@ -0,0 +1,90 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCronNext(t *testing.T) {
+	date := func(y int, m time.Month, d, h, min int) time.Time {
+		return time.Date(y, m, d, h, min, 0, 0, time.UTC)
+	}
+	// The 1st of January 2024 is a Monday
+	tests := []struct {
+		name  string
+		expr  string
+		after time.Time
+		want  time.Time
+	}{
+		{"every quarter", "*/15 * * * *", date(2024, 1, 1, 10, 7), date(2024, 1, 1, 10, 15)},
+		{"strictly after", "*/15 * * * *", date(2024, 1, 1, 10, 15), date(2024, 1, 1, 10, 30)},
+		{"seconds ignored", "0 * * * *", date(2024, 1, 1, 10, 59).Add(30 * time.Second), date(2024, 1, 1, 11, 0)},
+		{"weekdays", "0 9 * * 1-5", date(2024, 1, 5, 10, 0), date(2024, 1, 8, 9, 0)},
+		{"list", "0 8,20 * * *", date(2024, 1, 1, 9, 0), date(2024, 1, 1, 20, 0)},
+		{"range with step", "0-30/10 1 * * *", date(2024, 1, 1, 1, 25), date(2024, 1, 1, 1, 30)},
+		{"next month", "@daily", date(2024, 1, 31, 23, 59), date(2024, 2, 1, 0, 0)},
+		{"next year", "@monthly", date(2024, 12, 15, 0, 0), date(2025, 1, 1, 0, 0)},
+		{"hourly", "@hourly", date(2024, 1, 1, 23, 0), date(2024, 1, 2, 0, 0)},
+		{"weekly", "@weekly", date(2024, 1, 1, 0, 0), date(2024, 1, 7, 0, 0)},
+		{"sunday as 7", "0 0 * * 7", date(2024, 1, 1, 0, 0), date(2024, 1, 7, 0, 0)},
+		{"month", "0 0 1 6 *", date(2024, 1, 1, 0, 0), date(2024, 6, 1, 0, 0)},
+		{"day of month step", "0 0 */2 * *", date(2024, 1, 1, 0, 0), date(2024, 1, 3, 0, 0)},
+		{"day of month or day of week", "0 0 1 * 1", date(2024, 1, 1, 0, 0), date(2024, 1, 8, 0, 0)},
+		{"day of month or day of week in the next month", "0 0 1 * 1", date(2024, 1, 29, 0, 0), date(2024, 2, 1, 0, 0)},
+		// A day field starting with a star makes both of them apply
+		{"day of month step and day of week", "0 0 */2 * 1", date(2024, 1, 1, 0, 0), date(2024, 1, 15, 0, 0)},
+		{"day of month and day of week step", "0 0 15 * */3", date(2024, 1, 1, 0, 0), date(2024, 5, 15, 0, 0)},
+		{"leap day", "0 12 29 2 *", date(2024, 3, 1, 0, 0), date(2028, 2, 29, 12, 0)},
+		{"never", "0 0 31 2 *", date(2024, 1, 1, 0, 0), time.Time{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := ParseCron(tt.expr)
+			if err != nil {
+				t.Fatalf("ParseCron(%q): %v", tt.expr, err)
+			}
+			if got := c.Next(tt.after); !got.Equal(tt.want) {
+				t.Errorf("Next(%v) = %v, want %v", tt.after, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCronNextLocation(t *testing.T) {
+	loc, err := time.LoadLocation("Europe/Paris")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := ParseCron("0 9 * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Clocks go forward on the 31st of March 2024 in Paris
+	after := time.Date(2024, 3, 30, 10, 0, 0, 0, loc)
+	want := time.Date(2024, 3, 31, 9, 0, 0, 0, loc)
+	if got := c.Next(after); !got.Equal(want) {
+		t.Errorf("Next(%v) = %v, want %v", after, got, want)
+	}
+}
+
+func TestParseCronInvalid(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"a * * * *",
+		"1-a * * * *",
+		"@yearly",
+	} {
+		if _, err := ParseCron(expr); err == nil {
+			t.Errorf("ParseCron(%q) didn't fail", expr)
+		}
+	}
+}
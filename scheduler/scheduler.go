@@ -0,0 +1,139 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"time"
+	// Guild timezones must be available even without system tzdata
+	_ "time/tzdata"
+
+	"github.com/rs/zerolog"
+	"go.uber.org/fx"
+
+	"github.com/depado/fox/models"
+	"github.com/depado/fox/player"
+	"github.com/depado/fox/soundcloud"
+	"github.com/depado/fox/storage"
+)
+
+// requester is displayed as the requester of the tracks queued by a schedule
+const requester = "📅 Scheduled session"
+
+// Location returns the timezone of the guild, UTC if it's not set or invalid.
+func Location(gc *models.Conf) *time.Location {
+	if gc.Timezone == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(gc.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// Scheduler starts the scheduled sessions of the guilds when they are due.
+type Scheduler struct {
+	players *player.Players
+	storage *storage.BoltStorage
+	sp      *soundcloud.SoundCloudProvider
+	log     zerolog.Logger
+}
+
+// NewScheduler will create a new scheduler
+func NewScheduler(p *player.Players, bs *storage.BoltStorage, sp *soundcloud.SoundCloudProvider, l zerolog.Logger) *Scheduler {
+	return &Scheduler{
+		players: p,
+		storage: bs,
+		sp:      sp,
+		log:     l.With().Str("component", "scheduler").Logger(),
+	}
+}
+
+// Run will start the scheduler and stop it along with the application
+func Run(lc fx.Lifecycle, s *Scheduler) {
+	quit := make(chan struct{})
+	lc.Append(fx.Hook{
+		OnStart: func(c context.Context) error {
+			go s.loop(quit)
+			return nil
+		},
+		OnStop: func(c context.Context) error {
+			close(quit)
+			return nil
+		},
+	})
+}
+
+// loop will check the schedules at the start of every minute until quit is
+// closed
+func (s *Scheduler) loop(quit <-chan struct{}) {
+	for {
+		now := time.Now()
+		next := now.Truncate(time.Minute).Add(time.Minute)
+		select {
+		case <-time.After(next.Sub(now)):
+			s.tick(next)
+		case <-quit:
+			return
+		}
+	}
+}
+
+// tick will start the sessions due at the given time
+func (s *Scheduler) tick(t time.Time) {
+	for _, pl := range s.players.All() {
+		ss, err := s.storage.GetSchedules(pl.Guild)
+		if err != nil {
+			s.log.Err(err).Str("guild", pl.Guild).Msg("unable to get schedules")
+			continue
+		}
+		lt := t.In(Location(pl.GuildConf()))
+		for _, sc := range ss {
+			c, err := ParseCron(sc.Cron)
+			if err != nil {
+				s.log.Err(err).Str("guild", pl.Guild).Int("schedule", sc.ID).Msg("invalid cron expression")
+				continue
+			}
+			if c.Match(lt) {
+				go s.start(pl, sc)
+			}
+		}
+	}
+}
+
+// start will queue the playlist of the schedule and start playing it
+func (s *Scheduler) start(pl *player.Player, sc models.Schedule) {
+	log := s.log.With().Str("guild", pl.Guild).Int("schedule", sc.ID).Logger()
+	log.Info().Msg("starting scheduled session")
+
+	tr, err := s.sp.GetPlaylistTracks(sc.URL, requester)
+	if err != nil {
+		log.Err(err).Msg("unable to get playlist")
+		pl.SendNotice("📅 Scheduled session", fmt.Sprintf("I couldn't start the scheduled session #%d, the playlist is unavailable", sc.ID), "")
+		return
+	}
+	if sc.Shuffle {
+		rand.Shuffle(len(tr), func(i, j int) { tr[i], tr[j] = tr[j], tr[i] })
+	}
+	a := pl.Queue.AppendWithin(pl.GuildConf().Limits, tr...)
+
+	if sc.Volume > 0 {
+		if err := pl.SetVolumePercent(sc.Volume); err != nil {
+			log.Err(err).Msg("unable to set volume")
+		}
+	}
+	if sc.Duration > 0 {
+		pl.SleepIn(sc.Duration)
+	}
+
+	body := fmt.Sprintf("Session #%d started, **%d** tracks were added to the queue", sc.ID, a.Added)
+	if a.Dropped() > 0 {
+		body += "\n" + a.String()
+	}
+	if sc.Duration > 0 {
+		body += fmt.Sprintf("\nPlayback will stop <t:%d:R>", time.Now().Add(sc.Duration).Unix())
+	}
+	pl.SendNotice("📅 Scheduled session", body, sc.URL)
+	pl.Play()
+}
@@ -0,0 +1,106 @@
+package soundcloud
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/Depado/soundcloud"
+)
+
+// apiBase is the SoundCloud API used for the endpoints the client library
+// doesn't cover
+const apiBase = "https://api-v2.soundcloud.com"
+
+// ClientID is the SoundCloud client ID used by the client and by the direct
+// queries to the API
+type ClientID string
+
+// NewClientID will fetch a client ID from SoundCloud's homepage
+func NewClientID() (ClientID, error) {
+	id, err := soundcloud.NewClientIDFromPublicHTML()
+	if err != nil {
+		return "", fmt.Errorf("fetch client id: %w", err)
+	}
+	return ClientID(id), nil
+}
+
+// NewClient will create a SoundCloud client using the client ID
+func NewClient(id ClientID) *soundcloud.Client {
+	return soundcloud.NewClient(string(id))
+}
+
+// api queries the SoundCloud API directly, with the same client ID as the
+// client.
+type api struct {
+	http     *http.Client
+	clientID ClientID
+}
+
+func newAPI(id ClientID) *api {
+	return &api{http: &http.Client{Timeout: 10 * time.Second}, clientID: id}
+}
+
+// get will query the given path with the given parameters and unmarshal the
+// response in out.
+func (a *api) get(path string, params url.Values, out any) error {
+	params.Set("client_id", string(a.clientID))
+
+	resp, err := a.http.Get(apiBase + path + "?" + params.Encode())
+	if err != nil {
+		return fmt.Errorf("query endpoint %s: %w", path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("query endpoint %s: status code %d", path, resp.StatusCode)
+	}
+
+	if err = json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("unmarshal: %w", err)
+	}
+	return nil
+}
+
+// trackCollection is the response of the endpoints listing tracks
+type trackCollection struct {
+	Collection []soundcloud.Track `json:"collection"`
+}
+
+// related returns the tracks SoundCloud considers related to the given track.
+func (a *api) related(id int, limit int) ([]soundcloud.Track, error) {
+	var tc trackCollection
+	params := url.Values{"limit": {strconv.Itoa(limit)}}
+	if err := a.get("/tracks/"+strconv.Itoa(id)+"/related", params, &tc); err != nil {
+		return nil, fmt.Errorf("get related tracks: %w", err)
+	}
+	return tc.Collection, nil
+}
+
+// playlistCollection is the response of the endpoints listing playlists
+type playlistCollection struct {
+	Collection []soundcloud.Playlist `json:"collection"`
+}
+
+// searchTracks returns the tracks matching the query.
+func (a *api) searchTracks(q string, limit int) ([]soundcloud.Track, error) {
+	var tc trackCollection
+	params := url.Values{"q": {q}, "limit": {strconv.Itoa(limit)}}
+	if err := a.get("/search/tracks", params, &tc); err != nil {
+		return nil, fmt.Errorf("search tracks: %w", err)
+	}
+	return tc.Collection, nil
+}
+
+// searchPlaylists returns the playlists matching the query.
+func (a *api) searchPlaylists(q string, limit int) ([]soundcloud.Playlist, error) {
+	var pc playlistCollection
+	params := url.Values{"q": {q}, "limit": {strconv.Itoa(limit)}}
+	if err := a.get("/search/playlists", params, &pc); err != nil {
+		return nil, fmt.Errorf("search playlists: %w", err)
+	}
+	return pc.Collection, nil
+}
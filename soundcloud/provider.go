@@ -5,6 +5,7 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/depado/fox/models"
 	"github.com/depado/fox/tracks"
 	"github.com/Depado/soundcloud"
 	"github.com/bwmarrin/discordgo"
@@ -12,19 +13,26 @@ import (
 	"github.com/rs/zerolog"
 )
 
+// AutoplayUser is displayed as the requester of the tracks added by autoplay
+const AutoplayUser = "Autoplay"
+
 type SoundCloudProvider struct {
 	client *soundcloud.Client
+	api    *api
 	log    zerolog.Logger
 }
 
-func NewSoundCloudProvider(log zerolog.Logger, c *soundcloud.Client) *SoundCloudProvider {
+func NewSoundCloudProvider(log zerolog.Logger, c *soundcloud.Client, id ClientID) *SoundCloudProvider {
 	return &SoundCloudProvider{
 		client: c,
+		api:    newAPI(id),
 		log:    log.With().Str("component", "soundcloudprovider").Logger(),
 	}
 }
 
-func (sc *SoundCloudProvider) GetPlaylist(url string, m *discordgo.Message) (tracks.Tracks, *discordgo.MessageEmbed, error) {
+// playlist will fetch the playlist and its tracks, marked as added by the
+// given user.
+func (sc *SoundCloudProvider) playlist(url, user, userID, avatar string) (*soundcloud.Playlist, tracks.Tracks, error) {
 	pls, err := sc.client.Playlist().FromURL(url)
 	if err != nil {
 		return nil, nil, fmt.Errorf("unable to retrieve playlist: %w", err)
@@ -44,10 +52,26 @@ func (sc *SoundCloudProvider) GetPlaylist(url string, m *discordgo.Message) (tra
 		tr[i] = tracks.SoundcloudTrack{
 			Track:        *track,
 			TrackService: *ts,
-			User:         m.Author.Username + "#" + m.Author.Discriminator,
-			AvatarURL:    m.Author.AvatarURL(""),
+			User:         user,
+			UserID:       userID,
+			AvatarURL:    avatar,
 		}
 	}
+	return pl, tr, nil
+}
+
+// GetPlaylistTracks will fetch the tracks of the playlist, marked as added by
+// the given user.
+func (sc *SoundCloudProvider) GetPlaylistTracks(url, user string) (tracks.Tracks, error) {
+	_, tr, err := sc.playlist(url, user, "", "")
+	return tr, err
+}
+
+func (sc *SoundCloudProvider) GetPlaylist(url string, m *discordgo.Message) (tracks.Tracks, *discordgo.MessageEmbed, error) {
+	pl, tr, err := sc.playlist(url, Requester(m.Author), m.Author.ID, m.Author.AvatarURL(""))
+	if err != nil {
+		return nil, nil, err
+	}
 
 	e := &discordgo.MessageEmbed{
 		Title: pl.Title,
@@ -65,13 +89,19 @@ func (sc *SoundCloudProvider) GetPlaylist(url string, m *discordgo.Message) (tra
 		Thumbnail: &discordgo.MessageEmbedThumbnail{URL: pl.ArtworkURL},
 		Footer: &discordgo.MessageEmbedFooter{
 			IconURL: m.Author.AvatarURL(""),
-			Text:    "Added by " + m.Author.Username + "#" + m.Author.Discriminator,
+			Text:    "Added by " + Requester(m.Author),
 		},
 	}
 
 	return tr, e, nil
 }
 
+// Requester returns the name under which the tracks added by the user are
+// recorded
+func Requester(u *discordgo.User) string {
+	return u.Username + "#" + u.Discriminator
+}
+
 func (sc *SoundCloudProvider) GetTrack(url string, m *discordgo.Message) (tracks.Track, *discordgo.MessageEmbed, error) {
 	ts, t, err := sc.client.Track().FromURL(url)
 	if err != nil {
@@ -95,14 +125,85 @@ func (sc *SoundCloudProvider) GetTrack(url string, m *discordgo.Message) (tracks
 		Thumbnail: &discordgo.MessageEmbedThumbnail{URL: t.ArtworkURL},
 		Footer: &discordgo.MessageEmbedFooter{
 			IconURL: m.Author.AvatarURL(""),
-			Text:    "Added by " + m.Author.Username + "#" + m.Author.Discriminator,
+			Text:    "Added by " + Requester(m.Author),
 		},
 	}
 
 	return tracks.SoundcloudTrack{
 		Track:        *t,
 		TrackService: *ts,
-		User:         m.Author.Username + "#" + m.Author.Discriminator,
+		User:         Requester(m.Author),
+		UserID:       m.Author.ID,
 		AvatarURL:    m.Author.AvatarURL(""),
 	}, e, nil
 }
+
+// RestoreTracks will turn saved tracks back into playable tracks.
+func (sc *SoundCloudProvider) RestoreTracks(saved []models.SavedTrack) tracks.Tracks {
+	tr := make(tracks.Tracks, 0, len(saved))
+	for _, st := range saved {
+		ts, t, err := sc.client.Track().FromTrack(&st.Track, false)
+		if err != nil {
+			sc.log.Err(err).Int("track", st.Track.ID).Msg("unable to restore track")
+			continue
+		}
+		tr = append(tr, tracks.SoundcloudTrack{
+			Track:        *t,
+			TrackService: *ts,
+			User:         st.User,
+			UserID:       st.UserID,
+			AvatarURL:    st.AvatarURL,
+		})
+	}
+	return tr
+}
+
+// Related will return up to n tracks related to the given seed tracks, taking
+// turns between seeds. Tracks for which skip returns true are left out. The
+// returned tracks are marked as added by autoplay.
+func (sc *SoundCloudProvider) Related(seeds tracks.Tracks, n int, skip func(id string) bool) (tracks.Tracks, error) {
+	var err error
+	var candidates [][]soundcloud.Track
+	for _, s := range seeds {
+		st, ok := s.(tracks.SoundcloudTrack)
+		if !ok {
+			continue
+		}
+		rel, rerr := sc.api.related(st.Track.ID, n)
+		if rerr != nil {
+			err = rerr
+			sc.log.Err(rerr).Int("track", st.Track.ID).Msg("unable to get related tracks")
+			continue
+		}
+		candidates = append(candidates, rel)
+	}
+	if len(candidates) == 0 && err != nil {
+		return nil, err
+	}
+
+	tr := tracks.Tracks{}
+	seen := map[string]bool{}
+	for i := 0; len(tr) < n; i++ {
+		var left bool
+		for _, c := range candidates {
+			if i >= len(c) || len(tr) >= n {
+				continue
+			}
+			left = true
+			ts, t, err := sc.client.Track().FromTrack(&c[i], false)
+			if err != nil {
+				continue
+			}
+			st := tracks.SoundcloudTrack{Track: *t, TrackService: *ts, User: AutoplayUser}
+			if seen[st.ID()] || skip(st.ID()) {
+				continue
+			}
+			seen[st.ID()] = true
+			tr = append(tr, st)
+		}
+		if !left {
+			break
+		}
+	}
+	return tr, nil
+}
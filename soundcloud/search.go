@@ -0,0 +1,64 @@
+package soundcloud
+
+import (
+	"fmt"
+	"time"
+)
+
+// SearchResult is a track or playlist found by a search
+type SearchResult struct {
+	Title    string
+	Artist   string
+	URL      string
+	Duration time.Duration
+	// Tracks is the number of tracks of a playlist, zero for a track
+	Tracks int
+}
+
+// Playlist returns whether the result is a playlist
+func (r SearchResult) Playlist() bool {
+	return r.Tracks > 0
+}
+
+// MarkdownLink returns the title and artist of the result linking to it
+func (r SearchResult) MarkdownLink() string {
+	return fmt.Sprintf("[%s - %s](%s)", r.Title, r.Artist, r.URL)
+}
+
+// Search will return the tracks then the playlists matching the query, up to
+// the given number of each. The results can be added using their URL.
+func (sc *SoundCloudProvider) Search(query string, tracks, playlists int) ([]SearchResult, error) {
+	ts, err := sc.api.searchTracks(query, tracks)
+	if err != nil {
+		return nil, err
+	}
+	var res []SearchResult
+	for _, t := range ts {
+		res = append(res, SearchResult{
+			Title:    t.Title,
+			Artist:   t.User.Username,
+			URL:      t.PermalinkURL,
+			Duration: time.Duration(t.Duration) * time.Millisecond,
+		})
+	}
+
+	// Tracks are still worth showing when playlists can't be searched
+	pls, err := sc.api.searchPlaylists(query, playlists)
+	if err != nil {
+		sc.log.Err(err).Str("query", query).Msg("unable to search playlists")
+		return res, nil
+	}
+	for _, pl := range pls {
+		if pl.TrackCount == 0 {
+			continue
+		}
+		res = append(res, SearchResult{
+			Title:    pl.Title,
+			Artist:   pl.User.Username,
+			URL:      pl.PermalinkURL,
+			Duration: time.Duration(pl.Duration) * time.Millisecond,
+			Tracks:   pl.TrackCount,
+		})
+	}
+	return res, nil
+}
@@ -52,6 +52,9 @@ func NewBoltStorage(lc fx.Lifecycle, c *cmd.Conf, l zerolog.Logger) (*BoltStorag
 		if _, err = tx.CreateBucketIfNotExists([]byte(UsersBucket)); err != nil {
 			return err
 		}
+		if _, err = tx.CreateBucketIfNotExists([]byte(LoudnessBucket)); err != nil {
+			return err
+		}
 		return nil
 	})
 
@@ -0,0 +1,57 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/depado/fox/models"
+)
+
+// LoudnessBucket is the bucket storing the loudness measurements of tracks
+const LoudnessBucket = "loudness"
+
+var (
+	// ErrLoudnessNotFound is returned when no measurement exists for a track
+	ErrLoudnessNotFound = errors.New("loudness not found")
+)
+
+// GetLoudness will fetch the loudness measurements of the given track.
+func (bs *BoltStorage) GetLoudness(trackID string) (*models.Loudness, error) {
+	l := &models.Loudness{}
+
+	err := bs.db.View(func(t *bolt.Tx) error {
+		b := t.Bucket([]byte(LoudnessBucket))
+		if b == nil {
+			return ErrLoudnessNotFound
+		}
+		raw := b.Get([]byte(trackID))
+		if raw == nil {
+			return ErrLoudnessNotFound
+		}
+		if err := json.Unmarshal(raw, l); err != nil {
+			return fmt.Errorf("unmarshal loudness: %w", err)
+		}
+		return nil
+	})
+
+	return l, err
+}
+
+// SaveLoudness will save the loudness measurements of the given track.
+func (bs *BoltStorage) SaveLoudness(trackID string, l *models.Loudness) error {
+	return bs.db.Update(func(t *bolt.Tx) error {
+		b, err := t.CreateBucketIfNotExists([]byte(LoudnessBucket))
+		if err != nil {
+			return fmt.Errorf("create loudness bucket: %w", err)
+		}
+		if buf, err := json.Marshal(l); err != nil {
+			return fmt.Errorf("marshal loudness: %w", err)
+		} else if err := b.Put([]byte(trackID), buf); err != nil {
+			return fmt.Errorf("put loudness: %w", err)
+		}
+		return nil
+	})
+}
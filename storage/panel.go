@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/depado/fox/models"
+)
+
+// PanelKey is the key of the now-playing panel in the guild bucket
+const PanelKey = "panel"
+
+var (
+	// ErrPanelNotFound is returned when the guild has no panel message
+	ErrPanelNotFound = errors.New("panel not found")
+)
+
+// GetPanel will fetch the now-playing panel message of the guild.
+func (bs *BoltStorage) GetPanel(guildID string) (*models.Panel, error) {
+	p := &models.Panel{}
+
+	err := bs.db.View(func(t *bolt.Tx) error {
+		guilds := t.Bucket([]byte(GuildsBucket))
+		if guilds == nil {
+			return ErrGuildsBucketdNotFound
+		}
+		gb := guilds.Bucket([]byte(guildID))
+		if gb == nil {
+			return ErrGuildNotFound
+		}
+		raw := gb.Get([]byte(PanelKey))
+		if raw == nil {
+			return ErrPanelNotFound
+		}
+		if err := json.Unmarshal(raw, p); err != nil {
+			return fmt.Errorf("unmarshal panel: %w", err)
+		}
+		return nil
+	})
+
+	return p, err
+}
+
+// SavePanel will save the now-playing panel message in the guild bucket.
+func (bs *BoltStorage) SavePanel(guildID string, p *models.Panel) error {
+	return bs.db.Update(func(t *bolt.Tx) error {
+		guilds := t.Bucket([]byte(GuildsBucket))
+		if guilds == nil {
+			return ErrGuildsBucketdNotFound
+		}
+		gb := guilds.Bucket([]byte(guildID))
+		if gb == nil {
+			return ErrGuildNotFound
+		}
+		if buf, err := json.Marshal(p); err != nil {
+			return fmt.Errorf("marshal panel: %w", err)
+		} else if err := gb.Put([]byte(PanelKey), buf); err != nil {
+			return fmt.Errorf("put panel: %w", err)
+		}
+		return nil
+	})
+}
+
+// DeletePanel will remove the now-playing panel message from the guild bucket.
+func (bs *BoltStorage) DeletePanel(guildID string) error {
+	return bs.db.Update(func(t *bolt.Tx) error {
+		guilds := t.Bucket([]byte(GuildsBucket))
+		if guilds == nil {
+			return ErrGuildsBucketdNotFound
+		}
+		gb := guilds.Bucket([]byte(guildID))
+		if gb == nil {
+			return ErrGuildNotFound
+		}
+		if err := gb.Delete([]byte(PanelKey)); err != nil {
+			return fmt.Errorf("delete panel: %w", err)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,113 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/depado/fox/models"
+)
+
+// SchedulesKey is the key of the scheduled sessions in the guild bucket
+const SchedulesKey = "schedules"
+
+var (
+	// ErrScheduleNotFound is returned when the guild has no such schedule
+	ErrScheduleNotFound = errors.New("schedule not found")
+)
+
+func getSchedules(gb *bolt.Bucket) ([]models.Schedule, error) {
+	var ss []models.Schedule
+	raw := gb.Get([]byte(SchedulesKey))
+	if raw == nil {
+		return ss, nil
+	}
+	if err := json.Unmarshal(raw, &ss); err != nil {
+		return nil, fmt.Errorf("unmarshal schedules: %w", err)
+	}
+	return ss, nil
+}
+
+func putSchedules(gb *bolt.Bucket, ss []models.Schedule) error {
+	if buf, err := json.Marshal(ss); err != nil {
+		return fmt.Errorf("marshal schedules: %w", err)
+	} else if err := gb.Put([]byte(SchedulesKey), buf); err != nil {
+		return fmt.Errorf("put schedules: %w", err)
+	}
+	return nil
+}
+
+// GetSchedules will fetch the scheduled sessions of the guild. A guild without
+// any schedule returns an empty list.
+func (bs *BoltStorage) GetSchedules(guildID string) ([]models.Schedule, error) {
+	var ss []models.Schedule
+
+	err := bs.db.View(func(t *bolt.Tx) error {
+		guilds := t.Bucket([]byte(GuildsBucket))
+		if guilds == nil {
+			return ErrGuildsBucketdNotFound
+		}
+		gb := guilds.Bucket([]byte(guildID))
+		if gb == nil {
+			return ErrGuildNotFound
+		}
+		var err error
+		ss, err = getSchedules(gb)
+		return err
+	})
+
+	return ss, err
+}
+
+// AddSchedule will add a scheduled session to the guild, assigning it a new
+// ID.
+func (bs *BoltStorage) AddSchedule(guildID string, s *models.Schedule) error {
+	return bs.db.Update(func(t *bolt.Tx) error {
+		guilds := t.Bucket([]byte(GuildsBucket))
+		if guilds == nil {
+			return ErrGuildsBucketdNotFound
+		}
+		gb := guilds.Bucket([]byte(guildID))
+		if gb == nil {
+			return ErrGuildNotFound
+		}
+		ss, err := getSchedules(gb)
+		if err != nil {
+			return err
+		}
+		s.ID = 1
+		for _, e := range ss {
+			if e.ID >= s.ID {
+				s.ID = e.ID + 1
+			}
+		}
+		return putSchedules(gb, append(ss, *s))
+	})
+}
+
+// RemoveSchedule will remove the scheduled session with the given ID from the
+// guild.
+func (bs *BoltStorage) RemoveSchedule(guildID string, id int) error {
+	return bs.db.Update(func(t *bolt.Tx) error {
+		guilds := t.Bucket([]byte(GuildsBucket))
+		if guilds == nil {
+			return ErrGuildsBucketdNotFound
+		}
+		gb := guilds.Bucket([]byte(guildID))
+		if gb == nil {
+			return ErrGuildNotFound
+		}
+		ss, err := getSchedules(gb)
+		if err != nil {
+			return err
+		}
+		for i, e := range ss {
+			if e.ID == id {
+				return putSchedules(gb, append(ss[:i], ss[i+1:]...))
+			}
+		}
+		return ErrScheduleNotFound
+	})
+}
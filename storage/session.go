@@ -0,0 +1,65 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/depado/fox/models"
+)
+
+// SessionKey is the key of the saved player session in the guild bucket
+const SessionKey = "session"
+
+var (
+	// ErrSessionNotFound is returned when the guild has no saved session
+	ErrSessionNotFound = errors.New("session not found")
+)
+
+// GetSession will fetch the saved player session of the guild.
+func (bs *BoltStorage) GetSession(guildID string) (*models.Session, error) {
+	s := &models.Session{}
+
+	err := bs.db.View(func(t *bolt.Tx) error {
+		guilds := t.Bucket([]byte(GuildsBucket))
+		if guilds == nil {
+			return ErrGuildsBucketdNotFound
+		}
+		gb := guilds.Bucket([]byte(guildID))
+		if gb == nil {
+			return ErrGuildNotFound
+		}
+		raw := gb.Get([]byte(SessionKey))
+		if raw == nil {
+			return ErrSessionNotFound
+		}
+		if err := json.Unmarshal(raw, s); err != nil {
+			return fmt.Errorf("unmarshal session: %w", err)
+		}
+		return nil
+	})
+
+	return s, err
+}
+
+// SaveSession will save the player session in the guild bucket.
+func (bs *BoltStorage) SaveSession(guildID string, s *models.Session) error {
+	return bs.db.Update(func(t *bolt.Tx) error {
+		guilds := t.Bucket([]byte(GuildsBucket))
+		if guilds == nil {
+			return ErrGuildsBucketdNotFound
+		}
+		gb := guilds.Bucket([]byte(guildID))
+		if gb == nil {
+			return ErrGuildNotFound
+		}
+		if buf, err := json.Marshal(s); err != nil {
+			return fmt.Errorf("marshal session: %w", err)
+		} else if err := gb.Put([]byte(SessionKey), buf); err != nil {
+			return fmt.Errorf("put session: %w", err)
+		}
+		return nil
+	})
+}
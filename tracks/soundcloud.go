@@ -8,19 +8,45 @@ import (
 	"github.com/Depado/soundcloud"
 	"github.com/bwmarrin/discordgo"
 	"github.com/hako/durafmt"
+
+	"github.com/depado/fox/models"
 )
 
 type SoundcloudTrack struct {
 	Track        soundcloud.Track
 	TrackService soundcloud.TrackService
 	User         string
-	AvatarURL    string
+	// UserID is the Discord ID of the user who added the track, empty when
+	// the bot added it
+	UserID    string
+	AvatarURL string
+}
+
+// ID returns a unique identifier for the track
+func (t SoundcloudTrack) ID() string {
+	return "soundcloud:" + strconv.Itoa(t.Track.ID)
+}
+
+// Save returns the serializable form of the track
+func (t SoundcloudTrack) Save() models.SavedTrack {
+	tr := t.Track
+	if tr.Playlist != nil {
+		// The playlist's tracks reference the playlist itself
+		pl := *tr.Playlist
+		pl.Tracks = nil
+		tr.Playlist = &pl
+	}
+	return models.SavedTrack{Track: tr, User: t.User, UserID: t.UserID, AvatarURL: t.AvatarURL}
 }
 
 func (t SoundcloudTrack) GetUser() (string, string) {
 	return t.User, t.AvatarURL
 }
 
+func (t SoundcloudTrack) GetUserID() string {
+	return t.UserID
+}
+
 func (t SoundcloudTrack) ListenStatus() string {
 	return t.Track.Title + " - " + t.Track.User.Username
 }
@@ -1,14 +1,21 @@
 package tracks
 
-import "github.com/bwmarrin/discordgo"
+import (
+	"github.com/bwmarrin/discordgo"
+
+	"github.com/depado/fox/models"
+)
 
 type Track interface {
+	ID() string
 	StreamURL() (string, error)
 	Duration() int
 	Embed(duration bool) *discordgo.MessageEmbed
 	MarkdownLink() string
 	ListenStatus() string
 	GetUser() (string, string)
+	GetUserID() string
+	Save() models.SavedTrack
 }
 
 type Tracks []Track
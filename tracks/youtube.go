@@ -1,10 +1,18 @@
 package tracks
 
-import "github.com/bwmarrin/discordgo"
+import (
+	"github.com/bwmarrin/discordgo"
+
+	"github.com/depado/fox/models"
+)
 
 type YoutubeTrack struct {
 }
 
+func (yt YoutubeTrack) ID() string {
+	panic("not implemented")
+}
+
 func (yt YoutubeTrack) StreamURL() (string, error) {
 	panic("not implemented")
 }
@@ -24,3 +32,11 @@ func (yt YoutubeTrack) MarkdownLink() string {
 func (yt YoutubeTrack) ListenStatus() string {
 	panic("not implemented")
 }
+
+func (yt YoutubeTrack) GetUserID() string {
+	panic("not implemented")
+}
+
+func (yt YoutubeTrack) Save() models.SavedTrack {
+	panic("not implemented")
+}